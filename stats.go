@@ -3,14 +3,24 @@ package main
 
 import (
 	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"math"
+	"math/rand/v2"
 	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
 
 	"golang.org/x/term"
 )
@@ -33,30 +43,180 @@ type Stats struct {
 	Max               float64
 	StdDev            float64 // Standard Deviation
 	Variance          float64 // Variance = StdDev^2
+	RMS               float64 // Root Mean Square = sqrt(sum(x^2)/n)
+	StdDevCILevel     float64 // confidence level in percent for the StdDev interval (0 = disabled)
+	StdDevCILower     float64 // lower bound of the chi-square confidence interval for the population StdDev
+	StdDevCIUpper     float64 // upper bound of the chi-square confidence interval for the population StdDev
 	Q1                float64 // 1st Quartile (25th percentile)
 	Q3                float64 // 3rd Quartile (75th percentile)
+	P1                float64 // 1st percentile
+	P5                float64 // 5th percentile
+	P10               float64 // 10th percentile
 	P95               float64 // 95th percentile
 	P99               float64 // 99th percentile
 	IQR               float64 // Interquartile Range (Q3 - Q1)
+	Trimean           float64 // Tukey's trimean (Q1 + 2*Median + Q3) / 4
+	WithinOneSD       float64 // percent of values within 1 StdDev of the mean
+	WithinTwoSD       float64 // percent of values within 2 StdDev of the mean
+	WithinThreeSD     float64 // percent of values within 3 StdDev of the mean
 	Outliers          []float64
+	MildOutliers      []float64           // Outliers within Tukey's outer fences (3 * IQR)
+	ExtremeOutliers   []float64           // Outliers beyond Tukey's outer fences (3 * IQR)
 	ZScoreOutliers    []float64           // Outliers detected via Z-score method
 	ZScoreThreshold   float64             // Z-score threshold used (0 = disabled)
+	ConsensusOutliers []float64           // IQR outliers whose modified z-score also exceeds ConsensusModZ (-robust-outlier-consensus)
+	ConsensusModZ     float64             // modified z-score threshold used for ConsensusOutliers (0 = disabled)
 	Skewness          float64             // Formal skewness value
 	Kurtosis          float64             // Excess kurtosis
 	CV                float64             // Coefficient of Variation as a percentage
 	HasNegativeData   bool                // Flag for negative value warning
+	PositiveCount     int                 // values > 0
+	NegativeCount     int                 // values < 0
+	ZeroCount         int                 // values exactly 0
+	PositiveSum       float64             // sum of the values > 0
+	NegativeSum       float64             // sum of the values < 0
+	SumAbs            float64             // sum of the absolute values
+	DistinctCount     int                 // number of distinct values
 	CVValid           bool                // False when mean is near zero
 	CustomPercentiles map[float64]float64 // User-requested percentiles
 	Histogram         string              // Unicode histogram showing distribution
+	HistClipped       int                 // outliers excluded from the histogram by -hist-clip-outliers
+	ModeTolerance     float64             // cluster width for -mode-tol (0 = exact-value Mode)
+	ClusterMode       float64             // center of the most populous cluster (only valid when ClusterModeCount > 1)
+	ClusterModeCount  int                 // values in that cluster
+	ModalBin          *HistogramBin       // fullest histogram bin, an estimate of the mode for continuous data (-modal-bin)
+	QuartileMarkers   string              // marks the histogram bins holding Q1, the median, and Q3 (-percentile-spark)
 	Trendline         string              // Unicode trendline showing sequence pattern
+	CDFSparkline      string              // Unicode sparkline of the empirical CDF (non-decreasing)
 	TrimmedMean       float64
 	TrimmedMeanPct    float64 // 0 = disabled
+	TrimLowPct        float64 // asymmetric trimmed mean: percentage removed from the low tail
+	TrimHighPct       float64 // asymmetric trimmed mean: percentage removed from the high tail
 	TrimDatasetPct    float64 // 0 = disabled; trim dataset before all stats
 	TrimDatasetOrigN  int     // original count before dataset trimming
 	EMA               float64
-	EMASpan           int // 0 = disabled
+	EMASpan           int          // 0 = disabled
+	EMASeries         []float64    // EMA at each position, in input order
+	EMAAlpha          float64      // smoothing factor for EMASeries; 0 = disabled
+	EMATrendline      string       // Unicode trendline of EMASeries
+	MAD               float64      // Median Absolute Deviation
+	ScaledMAD         float64      // 1.4826 * MAD, consistent estimator of StdDev under normality
+	TrimmedRange      float64      // P(100-p) - P(p)
+	TrimmedRangePct   float64      // 0 = disabled
+	Duplicates        []ValueCount // values occurring more than once, most frequent first
+	Ranks             []float64    // fractional ranks parallel to the input (ties share the average rank)
+	ModZScores        []float64    // modified z-scores parallel to the input (-modzscores); nil when MAD is 0
+	AboveMean         int          // values greater than the mean
+	BelowMean         int          // values less than the mean
+	AtMean            int          // values equal to the mean
+	GeometricMean     float64      // only valid when PositiveMeans is true
+	HarmonicMean      float64      // only valid when PositiveMeans is true
+	PositiveMeans     bool         // all values are positive, so geometric and harmonic means are defined
+	MissingCount      int          // blank or invalid input lines skipped
+	NearConstant      bool         // StdDev/|Mean| below the near-constant threshold, or all values equal
+	Autocorr          float64      // sample autocorrelation at lag AutocorrLag
+	AutocorrLag       int          // 0 = disabled
+	AutocorrValid     bool         // false when the data is constant
+	TrendSlope        float64      // least-squares slope of value against input position 0..n-1
+	Volatility        float64      // mean(|x[i]-x[i-1]|) / |mean|, in input order
+	QQCorrelation     float64      // correlation of the sorted data with theoretical normal quantiles
+	QQValid           bool         // false for fewer than three values or constant data
+	Target            float64      // reference value for -target (only valid when HasTarget is true)
+	HasTarget         bool         // MAPE and Bias were computed against Target
+	WeightedMedian    float64      // median by cumulative weight (only valid when HasWeights is true)
+	HasWeights        bool         // values were read with weights (-weight-column)
+	MAPE              float64      // mean absolute percent error relative to Target
+	Bias              float64      // mean(x - Target)
+	Merged            bool         // result of MergeStats; order statistics, shape, and outliers are unavailable
 }
 
+// ReportOptions holds the display toggles for formatReport. They control how the report is laid out,
+// not what was computed, so they are kept out of Stats and its JSON output.
+type ReportOptions struct {
+	ShowMAD        bool // display raw and scaled MAD
+	FixedPrecision bool // format Mode and outlier values with Precision decimal places (-precision)
+	Precision      int  // decimal places used when FixedPrecision is true
+	ShowRelative   bool // display spread statistics as a percent of the mean (-relative)
+	ShowMissing    bool // display MissingCount
+	NoHeader       bool // omit section banners, leaving only label/value lines
+	Align          bool // line up the colons and right-align the numeric values
+	ShowVolatility bool // display Volatility (-volatility)
+	ShowTrendSlope bool // display TrendSlope (-trend-slope)
+	ShowQQ         bool // display QQCorrelation (-qq)
+	HistLog        bool // histogram bins are log-spaced (-hist-log)
+	HistInterp     bool // histogram heights are blended with neighbouring bins (-hist-interp)
+	HistEqualFreq  bool // histogram bins hold equal counts and the bars show density (-hist-equal-freq)
+}
+
+// OrderStatCI is a nonparametric confidence interval for a percentile, bounded by two order statistics.
+type OrderStatCI struct {
+	Lower      int     // 1-based rank of the lower bound in sorted order
+	Upper      int     // 1-based rank of the upper bound in sorted order
+	LowerValue float64 // value of the Lower-th order statistic
+	UpperValue float64 // value of the Upper-th order statistic
+	Coverage   float64 // exact coverage probability P(Lower <= B < Upper), B ~ Binomial(n, p)
+}
+
+// ChunkSummary holds summary statistics for one consecutive chunk of the input.
+type ChunkSummary struct {
+	Start int // 1-based position of the first value in the chunk
+	End   int // 1-based position of the last value in the chunk
+	Mean  float64
+	Min   float64
+	Max   float64
+}
+
+// ValueCount pairs a value with the number of times it occurs in the data.
+type ValueCount struct {
+	Value float64
+	Count int
+}
+
+// extremeOutlierMultiplier is the IQR multiplier for Tukey's outer fences.
+const extremeOutlierMultiplier = 3.0
+
+// histogramRamps are the character sets available for the histogram and trendline, from lowest to highest.
+// The number of characters in a ramp determines the number of quantization levels.
+var histogramRamps = map[string][]rune{
+	"blocks": []rune("▁▂▃▄▅▆▇█"),
+	"ascii":  []rune(".:-=+*#%@"),
+	"dots":   []rune("⡀⣀⣄⣤⣦⣶⣷⣿"),
+}
+
+// defaultRamp is the Unicode block ramp used unless -hist-chars selects another.
+var defaultRamp = histogramRamps["blocks"]
+
+// percentileFunc computes the p-th quantile (0 <= p <= 1) of sorted data.
+type percentileFunc func(sortedData []float64, p float64) float64
+
+// percentileMethods are the interpolation methods available via -pctl-method.
+var percentileMethods = map[string]percentileFunc{
+	"linear":   calculatePercentile,
+	"midpoint": calculatePercentileMidpoint,
+}
+
+// robustTrimPct is the trimmed mean percentage used by -robust when -t is not given.
+const robustTrimPct = 10.0
+
+// numberPattern matches the numeric tokens pulled from free text by -extract.
+var numberPattern = regexp.MustCompile(`-?\d+(\.\d+)?([eE][-+]?\d+)?`)
+
+// maxViolationsListed caps how many out-of-range values -expect-min/-expect-max lists.
+const maxViolationsListed = 10
+
+// madScaleFactor makes MAD a consistent estimator of the standard deviation for normal data.
+const madScaleFactor = 1.4826
+
+// modZScoreFactor is the 0.75 quantile of the standard normal distribution, which scales
+// (x - median) / MAD into Iglewicz and Hoaglin's modified z-score.
+const modZScoreFactor = 0.6745
+
+// defaultConsensusModZ is the default modified z-score threshold for -robust-outlier-consensus. A
+// modified z-score above 2.5 is the "median ± 2.5 × scaled MAD" rule that Leys et al. (2013)
+// recommend as moderately conservative; the stricter 3.5 of Iglewicz and Hoaglin is meant for the
+// modified z-score used alone, while here the IQR rule must agree as well.
+const defaultConsensusModZ = 2.5
+
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options] <filename | ->\n", os.Args[0])
@@ -73,6 +233,109 @@ func main() {
 	trimPct := flag.Float64("t", 0, "trimmed mean percentage to remove from each tail (0-50)")
 	trimDatasetPct := flag.Float64("T", 0, "trim dataset: remove percentage from each tail before computing all statistics (0-50)")
 	emaSpan := flag.Int("e", 0, "EMA span (number of periods) for exponential moving average (>= 2)")
+	madScaled := flag.Bool("mad-scaled", false, "show median absolute deviation (MAD) and scaled MAD (1.4826 * MAD)")
+	explain := flag.Bool("explain", false, "print a plain-English interpretation of the statistics after the report")
+	trimRangePct := flag.Float64("trim-range", 0, "trimmed range: difference between the (100-P)th and Pth percentiles (0-50)")
+	showSorted := flag.Bool("show-sorted", false, "print the sorted input values after the report")
+	showMeans := flag.Bool("means", false, "compare arithmetic, geometric, and harmonic means (AM >= GM >= HM)")
+	countMissing := flag.Bool("count-missing", false, "report the number of blank or invalid input lines that were skipped")
+	failOnOutliers := flag.Bool("fail-on-outliers", false, "exit with status 2 when outliers are detected (IQR, or Z-score when -z is set); the report is still printed")
+	constThreshold := flag.Float64("const-threshold", 0.001, "relative spread (StdDev/|Mean|) below which data is flagged as near-constant")
+	resample := flag.Int("resample", 0, "downsample the series to K buckets by averaging consecutive groups before computing statistics (>= 2)")
+	chunkSize := flag.Int("chunk", 0, "also report mean/min/max for consecutive chunks of N values (in input order)")
+	allowEmpty := flag.Bool("allow-empty", false, "exit successfully with a 'no data' message when the input contains no valid numbers")
+	templateFlag := flag.String("template", "", "Go text/template executed against the computed statistics, e.g. '{{.Mean}},{{.Median}}'")
+	jsonl := flag.Bool("jsonl", false, "output each computed result as a single-line JSON object (JSON Lines) with a timestamp; with -per-file, one record per file")
+	summaryJSON := flag.Bool("summary-json", false, "output the statistics as one JSON object with interpretation labels (skewness, kurtosis, CV) alongside the numbers")
+	extremes := flag.Bool("extremes", false, "fast path: report only count, min, and max in a single pass without storing the data")
+	pctlMethod := flag.String("pctl-method", "linear", "percentile interpolation method: linear or midpoint")
+	histChars := flag.String("hist-chars", "blocks", "character set for histogram and trendline: blocks, ascii, or dots")
+	showRanks := flag.Bool("ranks", false, "list the fractional rank of each value in input order (ties share the average rank)")
+	varianceContributors := flag.Int("variance-contributors", 0, "list the N values with the largest squared deviation from the mean and their percent share of the total sum of squares")
+	showDupes := flag.Bool("show-dupes", false, "list duplicate values and their counts after the report")
+	presorted := flag.Bool("sorted", false, "input is already sorted in non-decreasing order; skip sorting (errors if it is not)")
+	latency := flag.Bool("latency", false, "print only a compact latency percentile table (p50, p75, p90, p95, p99, p99.9)")
+	logShift := flag.Bool("log-shift", false, "apply shifted natural log transform ln(x - min + 1), allowing zero and negative values")
+	histClip := flag.Bool("hist-clip-outliers", false, "build the histogram over the non-outlier range (within the IQR fences) so extreme values don't flatten its shape")
+	cdfSpark := flag.Bool("cdf-spark", false, "add a sparkline of the cumulative distribution (ECDF) to the Distribution section")
+	targetFlag := flag.String("target", "", "known true value T: report mean absolute percent error and mean bias relative to T (T != 0)")
+	comparePctl := flag.String("compare-percentiles", "", "compare these comma-separated percentiles (0-100) between two file arguments A and B, with the delta B - A")
+	cohensDFlag := flag.Bool("cohens-d", false, "with -baseline, add Cohen's d effect size of the mean difference using the pooled standard deviation")
+	baselineFile := flag.String("baseline", "", "compare against a baseline file: print each statistic for both inputs with the delta and percent change")
+	excludeZerosFlag := flag.Bool("exclude-zeros", false, "drop values that are exactly zero before computing statistics (e.g. 'no reading' sensor values)")
+	nth := flag.Int("nth", 0, "print only the K-th smallest value (1 = min); negative K counts from the top (-1 = max)")
+	inputMode := flag.String("input", "plain", "input format: plain (one number per line) or keyed ('key value' per line, with a report per key)")
+	emaAlpha := flag.Float64("ema", 0, "smoothing factor alpha in (0,1) for an exponential moving average series, drawn as an EMA trendline")
+	autocorrLag := flag.Int("autocorr", 0, "sample autocorrelation of the input-ordered data at lag K (>= 1)")
+	robust := flag.Bool("robust", false, "print only a robust summary: median, MAD, IQR, Bowley skewness, and trimmed mean (-t, default 10%)")
+	pctlCI := flag.Float64("pctl-ci", 0, "nonparametric confidence interval for percentile P (0-100, exclusive) from order statistics")
+	ciLevel := flag.Float64("ci-level", 95, "confidence level in percent for confidence intervals (e.g. 90, 95, 99)")
+	noHeader := flag.Bool("no-header", false, "omit the '--- Section ---' banners from the report, leaving only 'Label: value' lines")
+	align := flag.Bool("align", false, "line up the report's colons and right-align numeric values in a column")
+	extract := flag.Bool("extract", false, "extract every number embedded in each line of text (e.g. 'latency=42.5ms'); lines without numbers are ignored")
+	trimLow := flag.Float64("trim-low", 0, "asymmetric trimmed mean: percentage to remove from the low tail (0-50; use with -trim-high)")
+	trimHigh := flag.Float64("trim-high", 0, "asymmetric trimmed mean: percentage to remove from the high tail (0-50; use with -trim-low)")
+	decilesFlag := flag.Bool("deciles", false, "output only the nine deciles P10, P20, ..., P90 as a JSON array")
+	histDensity := flag.Bool("hist-density", false, "with -hist-json, add each bin's probability density, count / (n * bin width), so the bars integrate to 1")
+	histJSON := flag.Bool("hist-json", false, "output only the histogram bins as a JSON array of {lower, upper, count} objects (bin count set by -b)")
+	progress := flag.Int("progress", 0, "print a line count to stderr every N input lines while reading (0 = silent)")
+	column := flag.Int("column", 0, "read only the K-th delimited field (1-based) of each line; see -delim")
+	delim := flag.String("delim", ",", "field delimiter for -column")
+	weightColumn := flag.Int("weight-column", 0, "with -column, read each value's non-negative weight from the K-th delimited field (1-based) of the same line; reports the weighted median and weights the histogram")
+	allColumns := flag.Bool("all-columns", false, "print a report for every delimited column (see -delim), labeled by 1-based index or by header name with -skip")
+	skip := flag.Int("skip", 0, "skip the first N input lines; with -all-columns, the last skipped line supplies the column names")
+	expectMin := flag.String("expect-min", "", "fail (exit 1) listing the values below this minimum")
+	expectMax := flag.String("expect-max", "", "fail (exit 1) listing the values above this maximum")
+	dump := flag.Bool("dump", false, "print the processed values (after transforms and filters) one per line instead of statistics")
+	qq := flag.Bool("qq", false, "report the QQ-plot correlation between the sorted data and normal quantiles (near 1 = normal)")
+	theoreticalPctl := flag.Float64("theoretical-pctl", 0, "compare percentile P (0-100, exclusive) under a fitted normal model (mean, stddev) with the empirical percentile")
+	bootstrap := flag.Int("bootstrap", 0, "bootstrap confidence interval for the mean from N resamples with replacement (see -ci-level and -seed)")
+	seedFlag := flag.String("seed", "", "random seed for -bootstrap: a non-negative integer, or 'auto' to derive it from a hash of the data (default: time-based)")
+	geomeanReport := flag.Bool("geomean-report", false, "report the geometric mean with a multiplicative confidence interval computed in log space (requires all-positive data; see -ci-level)")
+	stripUnitsFlag := flag.Bool("strip-units", false, "remove a trailing alphabetic unit suffix from each value before parsing, e.g. 42.5ms -> 42.5")
+	totals := flag.Bool("totals", false, "print the count, distinct count, sum, and sum of absolute values")
+	countBySign := flag.Bool("count-by-sign", false, "print the count and sum of positive, negative, and zero values")
+	percentileSpark := flag.Bool("percentile-spark", false, "print a marker line under the histogram showing the bins that hold Q1, the median, and Q3")
+	modalBinFlag := flag.Bool("modal-bin", false, "report the fullest histogram bin and its midpoint as an estimate of the mode for continuous data")
+	precision := flag.Int("precision", -1, "fixed number of decimal places for Mode, outlier, and -show-sorted values (default: trim trailing zeros)")
+	skewTest := flag.Bool("skew-test", false, "test whether the skewness differs significantly from 0 (z = skewness / SE, 5% level; needs 3+ values)")
+	kurtTest := flag.Bool("kurt-test", false, "test whether the excess kurtosis differs significantly from 0 (z = kurtosis / SE, 5% level; needs 4+ values)")
+	mergeSortedFlag := flag.Bool("merge-sorted", false, "treat each file argument as sorted ascending and compute exact Count, Min, Max, quartiles, and -p percentiles by a streaming k-way merge with bounded memory")
+	approxQuantiles := flag.Bool("approx-quantiles", false, "streaming path like -extremes, plus approximate quartiles and median from a bounded-memory t-digest")
+	rankOf := flag.String("rank-of", "", "comma-separated values whose percentile rank (percent of values at or below) to report")
+	modeTol := flag.Float64("mode-tol", 0, "report the mode as the center of the largest cluster of values whose neighbors are within this tolerance")
+	showWork := flag.Bool("show-work", false, "print the formula and intermediate terms for the mean, variance, standard deviation, and skewness")
+	outlierConsensus := flag.Bool("robust-outlier-consensus", false, "also report the outliers flagged by both the IQR rule and the modified z-score (see -consensus-modz)")
+	consensusModZ := flag.Float64("consensus-modz", defaultConsensusModZ, "modified z-score threshold for -robust-outlier-consensus")
+	modZScores := flag.Bool("modzscores", false, "list the modified z-score 0.6745*(x-median)/MAD of each value in input order")
+	splitNonNumeric := flag.Bool("split-nonnumeric", false, "split the whole input on any run of non-numeric characters and read every number, ignoring line structure")
+	sdCI := flag.Float64("sd-ci", 0, "chi-square confidence interval for the population standard deviation at this confidence level in percent (e.g. 95)")
+	saveBaselineFile := flag.String("save-baseline", "", "save the computed statistics as a JSON snapshot to this file for later -check-baseline runs")
+	checkBaselineFile := flag.String("check-baseline", "", "compare the mean against a snapshot saved with -save-baseline; exit 3 if it drifts beyond -tolerance")
+	tolerance := flag.Float64("tolerance", 5, "allowed mean drift in percent for -check-baseline")
+	fieldsFlag := flag.String("fields", "", "print only these comma-separated statistics, in order, e.g. mean,median,p95 (an unknown name lists the choices)")
+	detectClipping := flag.Bool("detect-clipping", false, "report how many values sit exactly at Min and Max and warn of possible sensor/ADC saturation")
+	normality := flag.Bool("normality", false, "run Jarque-Bera, QQ correlation, skewness, and kurtosis normality checks and conclude by majority (needs 4+ values)")
+	withinSD := flag.Bool("within-sd", false, "compare the percent of values within 1, 2, and 3 standard deviations of the mean to the 68-95-99.7 rule")
+	stemLeaf := flag.Bool("stemleaf", false, "print a stem-and-leaf plot of the data (best for small datasets)")
+	boxPlot := flag.Bool("boxplot", false, "print a horizontal ASCII box plot with whiskers and outlier markers (see -boxplot-width)")
+	boxPlotWidth := flag.Int("boxplot-width", 60, "width of the -boxplot rendering in columns (>= 10)")
+	trendSlope := flag.Bool("trend-slope", false, "show the least-squares slope of value against input position (positive = rising)")
+	detrendFlag := flag.Bool("detrend", false, "subtract the least-squares line over input position and compute statistics on the residuals")
+	scaleFlag := flag.String("scale", "", "linearly rescale the data so Min maps to A and Max maps to B, given as A:B (e.g. -1:1)")
+	eps := flag.Float64("eps", 0, "count values within this tolerance of each other as equal when finding the mode and duplicates (default: exact equality)")
+	filterFlag := flag.String("filter", "", "keep only values in the inclusive range LO:HI before computing statistics; either bound may be omitted (e.g. 0: or :100)")
+	pctChange := flag.Bool("pct-change", false, "transform the series into percent changes (x[i]-x[i-1])/x[i-1]*100 before computing statistics (n-1 values)")
+	scoreFlag := flag.Bool("score", false, "print a 0-100 health score averaging CV stability, outlier fraction, and normality, with its components")
+	volatility := flag.Bool("volatility", false, "show the mean absolute first difference divided by |mean|, a roughness measure in input order")
+	robustStd := flag.Bool("robust-standardize", false, "transform each value to (x - median) / (1.4826 * MAD) before computing statistics; combine with -dump to print them")
+	digitBiasFlag := flag.Bool("digit-bias", false, "check integer values for digit preference: last-digit distribution, chi-square uniformity, and heaping on 0s and 5s")
+	fitQuality := flag.Bool("fit-quality", false, "fit the least-squares line over input position and report RMSE, residual standard deviation, and the largest residual")
+	relative := flag.Bool("relative", false, "show Std Deviation, IQR, and Range as a percent of the mean (omitted when the mean is near zero)")
+	histInterp := flag.Bool("hist-interp", false, "smooth the histogram by blending each bar with its neighbours and rounding to the nearest level")
+	histEqualFreq := flag.Bool("hist-equal-freq", false, "use equal-frequency (quantile) histogram bins holding about the same number of values; bars show density and the bin ranges are listed")
+	histLog := flag.Bool("hist-log", false, "use logarithmically spaced histogram bins between Min and Max (requires all-positive data)")
+	perFile := flag.Bool("per-file", false, "compute a separate report for each file argument concurrently, followed by a combined report")
 	flag.Parse()
 
 	if *numBins < 5 || *numBins > 50 {
@@ -85,6 +348,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *consensusModZ <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -consensus-modz must be positive, got %v\n", *consensusModZ)
+		os.Exit(1)
+	}
+
 	if *trimPct < 0 || *trimPct > 50 {
 		fmt.Fprintf(os.Stderr, "Error: trim percentage must be between 0 and 50, got %v\n", *trimPct)
 		os.Exit(1)
@@ -95,70 +363,229 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *trimRangePct < 0 || *trimRangePct > 50 {
+		fmt.Fprintf(os.Stderr, "Error: trimmed range percentage must be between 0 and 50, got %v\n", *trimRangePct)
+		os.Exit(1)
+	}
+
 	if *emaSpan != 0 && *emaSpan < 2 {
 		fmt.Fprintf(os.Stderr, "Error: EMA span must be >= 2, got %d\n", *emaSpan)
 		os.Exit(1)
 	}
 
+	ramp, ok := histogramRamps[*histChars]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown histogram character set '%s'; choose blocks, ascii, or dots\n", *histChars)
+		os.Exit(1)
+	}
+
+	percentile, ok := percentileMethods[*pctlMethod]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown percentile method '%s'; choose linear or midpoint\n", *pctlMethod)
+		os.Exit(1)
+	}
+
+	if *resample != 0 && *resample < 2 {
+		fmt.Fprintf(os.Stderr, "Error: resample bucket count must be >= 2, got %d\n", *resample)
+		os.Exit(1)
+	}
+
+	if *constThreshold < 0 {
+		fmt.Fprintf(os.Stderr, "Error: near-constant threshold must be >= 0, got %v\n", *constThreshold)
+		os.Exit(1)
+	}
+
+	if *varianceContributors < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -variance-contributors must be >= 1, got %d\n", *varianceContributors)
+		os.Exit(1)
+	}
+
+	if *chunkSize < 0 {
+		fmt.Fprintf(os.Stderr, "Error: chunk size must be >= 1, got %d\n", *chunkSize)
+		os.Exit(1)
+	}
+
+	if *trimLow < 0 || *trimLow > 50 || *trimHigh < 0 || *trimHigh > 50 {
+		fmt.Fprintf(os.Stderr, "Error: -trim-low and -trim-high must be between 0 and 50, got %v and %v\n", *trimLow, *trimHigh)
+		os.Exit(1)
+	}
+
+	asymmetricTrim := *trimLow > 0 || *trimHigh > 0
+	if asymmetricTrim && (*trimPct > 0 || *trimDatasetPct > 0) {
+		fmt.Fprintf(os.Stderr, "Error: -trim-low/-trim-high cannot be combined with -t or -T\n")
+		os.Exit(1)
+	}
+
+	if *robust && *trimPct == 0 && *trimDatasetPct == 0 && !asymmetricTrim {
+		*trimPct = robustTrimPct
+	}
+
 	if *trimPct > 0 && *trimDatasetPct > 0 {
 		fmt.Fprintf(os.Stderr, "Error: -t and -T are mutually exclusive; use -t for trimmed mean only, or -T to trim the entire dataset\n")
 		os.Exit(1)
 	}
 
-	if *version {
-		fmt.Printf("%s version %s\n%s\n\n%s\n%s\n", PgmName, PgmVersion, PgmUrl, PgmDisclaimer, PgmSeeAlso)
-		os.Exit(0)
+	if *emaAlpha != 0 && (*emaAlpha <= 0 || *emaAlpha >= 1) {
+		fmt.Fprintf(os.Stderr, "Error: EMA alpha must be between 0 and 1 (exclusive), got %v\n", *emaAlpha)
+		os.Exit(1)
 	}
-	args := flag.Args()
-	// Determine whether stdin is a terminal
-	inputIsTerminal := term.IsTerminal(int(os.Stdin.Fd()))
 
-	if len(args) < 1 && inputIsTerminal {
-		flag.Usage()
-		os.Exit(0)
+	if *column < 0 {
+		fmt.Fprintf(os.Stderr, "Error: column must be >= 1, got %d\n", *column)
+		os.Exit(1)
 	}
 
-	var reader io.Reader
+	if *weightColumn < 0 {
+		fmt.Fprintf(os.Stderr, "Error: weight column must be >= 1, got %d\n", *weightColumn)
+		os.Exit(1)
+	}
 
-	if len(args) == 0 || args[0] == "-" {
-		// No args with piped input, or explicit "-" flag
-		reader = os.Stdin
-	} else {
-		file, err := os.Open(args[0])
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
-			os.Exit(1)
-		}
-		defer file.Close()
-		reader = file
+	if *weightColumn > 0 && *column == 0 {
+		fmt.Fprintf(os.Stderr, "Error: -weight-column requires -column for the values\n")
+		os.Exit(1)
 	}
 
-	numbers, err := readNumbers(reader)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading numbers: %v\n", err)
+	if *cohensDFlag && *baselineFile == "" {
+		fmt.Fprintf(os.Stderr, "Error: -cohens-d requires -baseline for the group to compare against\n")
 		os.Exit(1)
 	}
 
-	if *logTransform {
-		numbers, err = applyLogTransform(numbers)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
+	if *weightColumn > 0 && *weightColumn == *column {
+		fmt.Fprintf(os.Stderr, "Error: -weight-column and -column must be different fields, got %d for both\n", *column)
+		os.Exit(1)
 	}
 
-	originalCount := len(numbers)
-	if *trimDatasetPct > 0 {
-		sorted := make([]float64, len(numbers))
-		copy(sorted, numbers)
-		sort.Float64s(sorted)
-		trimCount := int(math.Floor(float64(len(sorted)) * *trimDatasetPct / 100.0))
-		remaining := len(sorted) - 2*trimCount
-		if remaining < 1 {
-			fmt.Fprintf(os.Stderr, "Error: dataset too small (%d values) to trim %.4g%% from each end\n", len(sorted), *trimDatasetPct)
+	if *weightColumn > 0 && (*histClip || *histLog || *histEqualFreq || *histInterp || *modalBinFlag) {
+		fmt.Fprintf(os.Stderr, "Error: the weighted histogram from -weight-column cannot be combined with -hist-clip-outliers, -hist-log, -hist-equal-freq, -hist-interp, or -modal-bin\n")
+		os.Exit(1)
+	}
+
+	// Weights are paired with values by position, so transforms that drop or reorder values are not allowed
+	if *weightColumn > 0 && (*excludeZerosFlag || *filterFlag != "" || *pctChange || *resample > 0 || *trimDatasetPct > 0) {
+		fmt.Fprintf(os.Stderr, "Error: -weight-column cannot be combined with -exclude-zeros, -filter, -pct-change, -resample, or -T\n")
+		os.Exit(1)
+	}
+
+	if *delim == "" {
+		fmt.Fprintf(os.Stderr, "Error: delimiter must not be empty\n")
+		os.Exit(1)
+	}
+
+	if *skip < 0 {
+		fmt.Fprintf(os.Stderr, "Error: skip count must be >= 0, got %d\n", *skip)
+		os.Exit(1)
+	}
+
+	if *progress < 0 {
+		fmt.Fprintf(os.Stderr, "Error: progress interval must be >= 1, got %d\n", *progress)
+		os.Exit(1)
+	}
+
+	if *autocorrLag < 0 {
+		fmt.Fprintf(os.Stderr, "Error: autocorrelation lag must be >= 1, got %d\n", *autocorrLag)
+		os.Exit(1)
+	}
+
+	if *autocorrLag > 0 && *trimDatasetPct > 0 {
+		fmt.Fprintf(os.Stderr, "Error: -autocorr and -T are mutually exclusive; trimming the dataset discards input order\n")
+		os.Exit(1)
+	}
+
+	if *pctlCI < 0 || *pctlCI >= 100 {
+		fmt.Fprintf(os.Stderr, "Error: percentile for -pctl-ci must be between 0 and 100 (exclusive), got %v\n", *pctlCI)
+		os.Exit(1)
+	}
+
+	if *theoreticalPctl < 0 || *theoreticalPctl >= 100 {
+		fmt.Fprintf(os.Stderr, "Error: percentile for -theoretical-pctl must be between 0 and 100 (exclusive), got %v\n", *theoreticalPctl)
+		os.Exit(1)
+	}
+
+	if *ciLevel <= 0 || *ciLevel >= 100 {
+		fmt.Fprintf(os.Stderr, "Error: confidence level must be between 0 and 100 (exclusive), got %v\n", *ciLevel)
+		os.Exit(1)
+	}
+
+	if *precision < -1 || *precision > 15 {
+		fmt.Fprintf(os.Stderr, "Error: precision must be between 0 and 15, got %d\n", *precision)
+		os.Exit(1)
+	}
+
+	if *eps < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -eps must be non-negative, got %v\n", *eps)
+		os.Exit(1)
+	}
+
+	if *modeTol < 0 {
+		fmt.Fprintf(os.Stderr, "Error: mode tolerance must be non-negative, got %v\n", *modeTol)
+		os.Exit(1)
+	}
+
+	if *sdCI < 0 || *sdCI >= 100 {
+		fmt.Fprintf(os.Stderr, "Error: -sd-ci confidence level must be between 0 and 100 (exclusive), got %v\n", *sdCI)
+		os.Exit(1)
+	}
+
+	if *tolerance < 0 {
+		fmt.Fprintf(os.Stderr, "Error: tolerance must be non-negative, got %v\n", *tolerance)
+		os.Exit(1)
+	}
+
+	if *boxPlotWidth < 10 {
+		fmt.Fprintf(os.Stderr, "Error: box plot width must be at least 10, got %d\n", *boxPlotWidth)
+		os.Exit(1)
+	}
+
+	if *bootstrap < 0 {
+		fmt.Fprintf(os.Stderr, "Error: bootstrap resample count must be non-negative, got %d\n", *bootstrap)
+		os.Exit(1)
+	}
+
+	if *seedFlag != "" && *seedFlag != "auto" {
+		if _, err := strconv.ParseUint(*seedFlag, 10, 64); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -seed '%s'; use a non-negative integer or 'auto'\n", *seedFlag)
 			os.Exit(1)
 		}
-		numbers = sorted[trimCount : len(sorted)-trimCount]
+	}
+
+	if *splitNonNumeric && (*extract || *column > 0 || *stripUnitsFlag) {
+		fmt.Fprintf(os.Stderr, "Error: -split-nonnumeric reads every number in the input and cannot be combined with -extract, -column, or -strip-units\n")
+		os.Exit(1)
+	}
+
+	if *stripUnitsFlag && (*extract || *column > 0) {
+		fmt.Fprintf(os.Stderr, "Error: -strip-units applies to one value per line and cannot be combined with -extract or -column\n")
+		os.Exit(1)
+	}
+
+	if *inputMode != "plain" && *inputMode != "keyed" {
+		fmt.Fprintf(os.Stderr, "Error: unknown input format '%s'; choose plain or keyed\n", *inputMode)
+		os.Exit(1)
+	}
+
+	if *logTransform && *logShift {
+		fmt.Fprintf(os.Stderr, "Error: -l and -log-shift are mutually exclusive; use -l for the strict log transform, or -log-shift to allow zero and negative values\n")
+		os.Exit(1)
+	}
+
+	if *detrendFlag && *presorted {
+		fmt.Fprintf(os.Stderr, "Error: -detrend and -sorted are mutually exclusive; detrended residuals are not sorted\n")
+		os.Exit(1)
+	}
+
+	if *robustStd && *scaleFlag != "" {
+		fmt.Fprintf(os.Stderr, "Error: -robust-standardize and -scale are mutually exclusive; both rescale the data\n")
+		os.Exit(1)
+	}
+
+	if *histEqualFreq && (*histLog || *histInterp) {
+		fmt.Fprintf(os.Stderr, "Error: -hist-equal-freq cannot be combined with -hist-log or -hist-interp\n")
+		os.Exit(1)
+	}
+
+	if *geomeanReport && (*logTransform || *logShift) {
+		fmt.Fprintf(os.Stderr, "Error: -geomean-report applies its own log transform and cannot be combined with -l or -log-shift\n")
+		os.Exit(1)
 	}
 
 	var customPercentiles []float64
@@ -177,16 +604,83 @@ func main() {
 		}
 	}
 
-	stats, err := computeStats(numbers, customPercentiles, *iqrMultiplier, *numBins, *zScoreThreshold, *trimPct, *emaSpan)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error computing stats: %v\n", err)
-		os.Exit(1)
+	var fields []string
+	if *fieldsFlag != "" {
+		for _, name := range strings.Split(*fieldsFlag, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, ok := lookupField(name); !ok {
+				fmt.Fprintf(os.Stderr, "Error: unknown field '%s'; choose from %s\n", name, strings.Join(fieldNames(), ", "))
+				os.Exit(1)
+			}
+			fields = append(fields, name)
+		}
 	}
 
-	if *trimDatasetPct > 0 {
-		stats.TrimDatasetPct = *trimDatasetPct
-		stats.TrimDatasetOrigN = originalCount
-		stats.Trendline = ""
+	var rankQueries []float64
+	if *rankOf != "" {
+		for _, s := range strings.Split(*rankOf, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid -rank-of value '%s'\n", s)
+				os.Exit(1)
+			}
+			rankQueries = append(rankQueries, v)
+		}
+	}
+
+	var target float64
+	if *targetFlag != "" {
+		var err error
+		target, err = strconv.ParseFloat(strings.TrimSpace(*targetFlag), 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid target value '%s'\n", *targetFlag)
+			os.Exit(1)
+		}
+		if target == 0 {
+			fmt.Fprintf(os.Stderr, "Error: target must not be 0; percent error divides by the target\n")
+			os.Exit(1)
+		}
+	}
+
+	var scaleLo, scaleHi float64
+	if *scaleFlag != "" {
+		var err error
+		scaleLo, scaleHi, err = parseRange(*scaleFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -scale value: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	filterLo, filterHi := math.Inf(-1), math.Inf(1)
+	if *filterFlag != "" {
+		var err error
+		filterLo, filterHi, err = parseOpenRange(*filterFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -filter value: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	lowerLimit, upperLimit := math.Inf(-1), math.Inf(1)
+	for _, limit := range []struct {
+		flag  string
+		value *string
+		dest  *float64
+	}{{"expect-min", expectMin, &lowerLimit}, {"expect-max", expectMax, &upperLimit}} {
+		if *limit.value == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(*limit.value), 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -%s value '%s'\n", limit.flag, *limit.value)
+			os.Exit(1)
+		}
+		*limit.dest = v
+	}
+	if lowerLimit > upperLimit {
+		fmt.Fprintf(os.Stderr, "Error: -expect-min %s is greater than -expect-max %s\n", formatFloat(lowerLimit), formatFloat(upperLimit))
+		os.Exit(1)
 	}
 
 	labelWidth := 18 // len("Quartile 1 (p25):")
@@ -202,395 +696,4275 @@ func main() {
 			labelWidth = len(label)
 		}
 	}
+	if *outlierConsensus {
+		label := fmt.Sprintf("Consensus Outliers (M>%s)*:", formatFloat(*consensusModZ))
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
 	if *trimPct > 0 {
 		label := fmt.Sprintf("Trimmed Mean (%s%%):", formatFloat(*trimPct))
 		if len(label) > labelWidth {
 			labelWidth = len(label)
 		}
 	}
+	if asymmetricTrim {
+		label := fmt.Sprintf("Trimmed Mean (%s%%/%s%%):", formatFloat(*trimLow), formatFloat(*trimHigh))
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
 	if *emaSpan > 0 {
 		label := fmt.Sprintf("EMA (span %d):", *emaSpan)
 		if len(label) > labelWidth {
 			labelWidth = len(label)
 		}
 	}
-	if *trimDatasetPct > 0 {
-		labelWidth++ // account for * suffix on labels
+	if *trimRangePct > 0 {
+		label := fmt.Sprintf("Trimmed Range (%s%%):", formatFloat(*trimRangePct))
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
 	}
-	labelWidth++ // ensure padding via fmt.Sprintf, not the label+space fallback in padLabel
-	if *logTransform {
-		fmt.Println("(log-transformed, base e)")
-		fmt.Println()
+	if *autocorrLag > 0 {
+		label := fmt.Sprintf("Autocorr (lag %d):", *autocorrLag)
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+	if *sdCI > 0 {
+		label := fmt.Sprintf("Std Dev (%s%% CI):", formatFloat(*sdCI))
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+	if *modeTol > 0 {
+		label := fmt.Sprintf("Mode (tol %s):", formatFloat(*modeTol))
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+	if *histEqualFreq {
+		if label := "Histogram (equal-freq):"; len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+	if *histInterp {
+		label := "Histogram (smoothed):"
+		if *histLog {
+			label = "Histogram (log, smoothed):"
+		}
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
 	}
 	if *trimDatasetPct > 0 {
-		fmt.Printf("(trimmed dataset: %s%% from each tail, %d → %d values)\n", formatFloat(*trimDatasetPct), originalCount, stats.Count)
-		fmt.Println()
+		labelWidth++ // account for * suffix on labels
 	}
-	printStats(stats, labelWidth)
-}
+	labelWidth++ // ensure padding via fmt.Sprintf, not the label+space fallback in padLabel
 
-// readNumbers reads floating-point numbers (one per line) from an io.Reader.
-func readNumbers(reader io.Reader) ([]float64, error) {
-	var numbers []float64
-	scanner := bufio.NewScanner(reader)
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue // Skip empty lines
+	if *version {
+		fmt.Printf("%s version %s\n%s\n\n%s\n%s\n", PgmName, PgmVersion, PgmUrl, PgmDisclaimer, PgmSeeAlso)
+		os.Exit(0)
+	}
+	args := flag.Args()
+	// Determine whether stdin is a terminal
+	inputIsTerminal := term.IsTerminal(int(os.Stdin.Fd()))
+
+	if len(args) < 1 && inputIsTerminal {
+		flag.Usage()
+		os.Exit(0)
+	}
+
+	opts := ReportOptions{
+		ShowMAD:        *madScaled,
+		ShowRelative:   *relative,
+		ShowTrendSlope: *trendSlope,
+		ShowMissing:    *countMissing,
+		NoHeader:       *noHeader,
+		Align:          *align,
+	}
+	if *precision >= 0 {
+		opts.FixedPrecision = true
+		opts.Precision = *precision
+	}
+
+	statsOpts := StatsOptions{EMASpan: *emaSpan, TrimRangePct: *trimRangePct, Presorted: *presorted, Ramp: ramp, Percentile: percentile}
+	compute := func(numbers []float64) (*Stats, error) {
+		return computeStats(numbers, customPercentiles, *iqrMultiplier, *numBins, *zScoreThreshold, *trimPct, statsOpts)
+	}
+
+	if *perFile {
+		names := args
+		if len(names) == 0 {
+			names = []string{"-"}
+		}
+		results, combined, err := computePerFile(names, openInput, compute)
+		exitCode := 0
+		if *jsonl {
+			// One record per file, as each file is an update to the monitored series
+			for _, r := range results {
+				if r.Err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s: %v\n", r.Name, r.Err)
+					exitCode = 1
+					continue
+				}
+				if err := writeJSONL(os.Stdout, r.Stats, r.Name, time.Now()); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			os.Exit(exitCode)
+		}
+		for _, r := range results {
+			fmt.Printf("=== %s ===\n", r.Name)
+			if r.Err != nil {
+				fmt.Printf("Error: %v\n\n", r.Err)
+				exitCode = 1
+				continue
+			}
+			printStats(r.Stats, opts, labelWidth)
+			fmt.Println()
+		}
+		fmt.Println("=== Combined ===")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		printStats(combined, opts, labelWidth)
+		os.Exit(exitCode)
+	}
+
+	if *comparePctl != "" {
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "Error: -compare-percentiles requires exactly two file arguments, got %d\n", len(args))
+			os.Exit(1)
+		}
+		var ps []float64
+		for _, f := range strings.Split(*comparePctl, ",") {
+			p, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+			if err != nil || p < 0 || p > 100 {
+				fmt.Fprintf(os.Stderr, "Error: invalid -compare-percentiles value '%s'; use numbers between 0 and 100\n", f)
+				os.Exit(1)
+			}
+			ps = append(ps, p)
+		}
+		var groups [2][]float64
+		for i, name := range args {
+			numbers, _, err := readInputNumbers(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			if len(numbers) == 0 {
+				fmt.Fprintf(os.Stderr, "Error: %s contains no valid numbers\n", name)
+				os.Exit(1)
+			}
+			sort.Float64s(numbers)
+			groups[i] = numbers
+		}
+		fmt.Printf("--- Percentile Comparison (A = %s, B = %s) ---\n", args[0], args[1])
+		fmt.Print(formatPercentileComparison(comparePercentiles(groups[0], groups[1], ps, percentile)))
+		return
+	}
+
+	if *baselineFile != "" {
+		currentFile := "-"
+		if len(args) > 0 {
+			currentFile = args[0]
+		}
+		var runs [2]*Stats
+		for i, name := range []string{*baselineFile, currentFile} {
+			numbers, skipped, err := readInputNumbers(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			runs[i], err = compute(numbers)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error computing stats for %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			runs[i].MissingCount = skipped
+		}
+		fmt.Printf("--- Baseline Comparison (%s → %s) ---\n", *baselineFile, currentFile)
+		fmt.Print(formatBaselineDiff(runs[0], runs[1]))
+		if *cohensDFlag {
+			d, err := cohensD(runs[0], runs[1])
+			if err != nil {
+				fmt.Printf("\nCohen's d: N/A (%v)\n", err)
+			} else {
+				fmt.Printf("\nCohen's d: %s (%s effect)\n", formatFloat(d), interpretCohensD(d))
+			}
+		}
+		return
+	}
+
+	if *mergeSortedFlag {
+		if len(args) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: -merge-sorted requires one or more sorted file arguments\n")
+			os.Exit(1)
+		}
+		ps := []float64{0, 0.25, 0.5, 0.75, 1}
+		for _, p := range customPercentiles {
+			ps = append(ps, p/100)
+		}
+		n, quantiles, err := mergeSortedFiles(args, openInput, ps, percentile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Printf("--- Sorted Merge (%d files) ---\n", len(args))
+		fmt.Print(formatMergedQuantiles(n, quantiles, customPercentiles))
+		return
+	}
+
+	var reader io.Reader
 
-		num, err := strconv.ParseFloat(line, 64)
+	if len(args) == 0 || args[0] == "-" {
+		// No args with piped input, or explicit "-" flag
+		reader = os.Stdin
+	} else {
+		file, err := os.Open(args[0])
 		if err != nil {
-			// Log invalid lines but continue processing
-			fmt.Fprintf(
-				os.Stderr,
-				"Warning: skipping invalid number on line %d: '%s'\n",
-				lineNum,
-				scanner.Text(),
-			)
+			fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	if *progress > 0 {
+		reader = &progressReader{r: reader, every: *progress, report: func(lines int) {
+			fmt.Fprintf(os.Stderr, "Progress: %d lines read\n", lines)
+		}}
+	}
+
+	var header string
+	if *skip > 0 {
+		var err error
+		reader, header, err = skipLines(reader, *skip)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading numbers: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *allColumns {
+		columns, missing, err := readAllColumns(reader, *delim)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading numbers: %v\n", err)
+			os.Exit(1)
+		}
+		if len(columns) == 0 {
+			fmt.Fprintf(os.Stderr, "Error computing stats: input contains no valid numbers\n")
+			os.Exit(1)
+		}
+		names := columnNames(header, *delim, len(columns))
+		for i, values := range columns {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("=== %s ===\n", names[i])
+			stats, err := compute(values)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			stats.MissingCount = missing[i]
+			printStats(stats, opts, labelWidth)
+		}
+		return
+	}
+
+	if *extremes || *approxQuantiles {
+		var digest *tDigest
+		if *approxQuantiles {
+			digest = newTDigest(tDigestCompression)
+		}
+		stats, err := computeExtremes(reader, digest)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error computing stats: %v\n", err)
+			os.Exit(1)
+		}
+		printExtremes(stats)
+		if digest != nil {
+			fmt.Println("\n--- Approximate Quantiles (t-digest) ---")
+			fmt.Print(formatDigestQuantiles(digest))
+		}
+		return
+	}
+
+	if *inputMode == "keyed" {
+		groups, missing, unkeyed, err := readKeyedNumbers(reader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading numbers: %v\n", err)
+			os.Exit(1)
+		}
+		if len(groups) == 0 {
+			fmt.Fprintf(os.Stderr, "Error computing stats: input contains no valid 'key value' lines\n")
+			os.Exit(1)
+		}
+		keys := make([]string, 0, len(groups))
+		for key := range groups {
+			keys = append(keys, key)
+		}
+		for key := range missing {
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key) // every value was invalid; reported as an error below
+			}
+		}
+		sort.Strings(keys)
+		if *countMissing && unkeyed > 0 {
+			noun := "lines"
+			if unkeyed == 1 {
+				noun = "line"
+			}
+			fmt.Printf("(skipped %d blank or malformed %s without a key)\n\n", unkeyed, noun)
+		}
+		for i, key := range keys {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("=== %s ===\n", key)
+			stats, err := compute(groups[key])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			stats.MissingCount = missing[key]
+			printStats(stats, opts, labelWidth)
+		}
+		return
+	}
+
+	var numbers, weights []float64
+	var missingCount int
+	var err error
+	if *extract {
+		numbers, missingCount, err = readExtractedNumbers(reader)
+	} else if *splitNonNumeric {
+		numbers, missingCount, err = readSplitNumbers(reader)
+	} else if *weightColumn > 0 {
+		numbers, weights, missingCount, err = readWeightedColumnNumbers(reader, *column, *weightColumn, *delim)
+	} else if *column > 0 {
+		numbers, missingCount, err = readColumnNumbers(reader, *column, *delim)
+	} else if *stripUnitsFlag {
+		numbers, missingCount, err = readNumbersStrippingUnits(reader)
+	} else {
+		numbers, missingCount, err = readNumbersWithMissing(reader)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading numbers: %v\n", err)
+		os.Exit(1)
+	}
+
+	if violations := findOutOfRange(numbers, lowerLimit, upperLimit); len(violations) > 0 {
+		fmt.Fprint(os.Stderr, formatViolations(numbers, violations, lowerLimit, upperLimit))
+		os.Exit(1)
+	}
+
+	zerosExcluded := 0
+	if *excludeZerosFlag {
+		before := len(numbers)
+		numbers = excludeZeros(numbers)
+		zerosExcluded = before - len(numbers)
+	}
+
+	filteredOut := 0
+	if *filterFlag != "" {
+		numbers, filteredOut = filterRange(numbers, filterLo, filterHi)
+	}
+
+	pctChangeFrom := len(numbers)
+	if *pctChange && len(numbers) > 0 {
+		numbers, err = percentChanges(numbers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *logTransform {
+		numbers, err = applyLogTransform(numbers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var logShiftAmount float64
+	if *logShift && len(numbers) > 0 {
+		numbers, logShiftAmount = applyShiftedLogTransform(numbers)
+	}
+
+	resampledFrom := len(numbers)
+	if *resample > 0 {
+		numbers = resampleSeries(numbers, *resample)
+	}
+
+	var detrendSlope, detrendIntercept float64
+	if *detrendFlag {
+		numbers, detrendSlope, detrendIntercept = detrend(numbers)
+	}
+
+	if *scaleFlag != "" {
+		numbers = scaleToRange(numbers, scaleLo, scaleHi)
+	}
+
+	var robustCenter, robustScale float64
+	if *robustStd && len(numbers) > 0 {
+		numbers, robustCenter, robustScale, err = robustStandardize(numbers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if len(numbers) == 0 && *allowEmpty {
+		fmt.Println("No data: input contains no valid numbers")
+		os.Exit(0)
+	}
+
+	originalCount := len(numbers)
+	if *trimDatasetPct > 0 {
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		trimCount := int(math.Floor(float64(len(sorted)) * *trimDatasetPct / 100.0))
+		remaining := len(sorted) - 2*trimCount
+		if remaining < 1 {
+			fmt.Fprintf(os.Stderr, "Error: dataset too small (%d values) to trim %.4g%% from each end\n", len(sorted), *trimDatasetPct)
+			os.Exit(1)
+		}
+		numbers = sorted[trimCount : len(sorted)-trimCount]
+	}
+
+	if *dump {
+		if err := writeValues(os.Stdout, numbers); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing values: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *nth != 0 {
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		v, err := nthValue(sorted, *nth)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(formatFloat(v))
+		os.Exit(0)
+	}
+
+	stats, err := computeStats(numbers, customPercentiles, *iqrMultiplier, *numBins, *zScoreThreshold, *trimPct, statsOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error computing stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *trimDatasetPct > 0 {
+		stats.TrimDatasetPct = *trimDatasetPct
+		stats.TrimDatasetOrigN = originalCount
+		stats.Trendline = ""
+	}
+	var histData []float64
+	var histBins []HistogramBin
+	if *histClip || *histLog || *histJSON || *percentileSpark || *modalBinFlag || *histInterp || *histEqualFreq || weights != nil {
+		histData = make([]float64, len(numbers))
+		copy(histData, numbers)
+		sort.Float64s(histData)
+		if *histClip && len(stats.Outliers) > 0 {
+			histData = withinFences(numbers, stats, *iqrMultiplier)
+			stats.HistClipped = len(stats.Outliers)
+		}
+		if *histLog {
+			histBins, err = computeLogHistogramBins(histData, *numBins)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			opts.HistLog = true
+		} else if *histEqualFreq {
+			histBins = computeEqualFrequencyBins(histData, *numBins)
+			for i, d := range histogramDensities(histBins) {
+				histBins[i].Density = &d
+			}
+			opts.HistEqualFreq = true
+		} else if weights != nil {
+			histBins = computeWeightedHistogramBins(numbers, weights, *numBins)
+		} else {
+			histBins = computeHistogramBins(histData, *numBins)
+		}
+		if *histInterp {
+			stats.Histogram = renderInterpolatedHistogram(histBins, ramp)
+			opts.HistInterp = true
+		} else {
+			stats.Histogram = renderHistogram(histBins, ramp)
+		}
+		if *modalBinFlag {
+			stats.ModalBin = modalBin(histBins)
+		}
+		if *percentileSpark {
+			stats.QuartileMarkers = quartileMarkers(histBins, stats.Q1, stats.Median, stats.Q3)
+		}
+	}
+	if asymmetricTrim {
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		stats.TrimmedMean, err = calculateTrimmedMean(sorted, *trimLow, *trimHigh)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error computing stats: %v\n", err)
+			os.Exit(1)
+		}
+		stats.TrimLowPct = *trimLow
+		stats.TrimHighPct = *trimHigh
+	}
+	if *autocorrLag > 0 {
+		if len(numbers) <= *autocorrLag+1 {
+			fmt.Fprintf(os.Stderr, "Error: autocorrelation at lag %d requires more than %d values, got %d\n", *autocorrLag, *autocorrLag+1, len(numbers))
+			os.Exit(1)
+		}
+		stats.AutocorrLag = *autocorrLag
+		stats.Autocorr, stats.AutocorrValid = calculateAutocorrelation(numbers, stats.Mean, *autocorrLag)
+	}
+	if *qq {
+		opts.ShowQQ = true
+		stats.QQCorrelation, stats.QQValid = calculateQQCorrelation(numbers)
+	}
+	if *emaAlpha > 0 {
+		stats.EMAAlpha = *emaAlpha
+		stats.EMASeries = calculateEMASeries(numbers, *emaAlpha)
+		if *trimDatasetPct == 0 {
+			stats.EMATrendline = generateTrendline(stats.EMASeries, *numBins, ramp)
+		}
+	}
+	if *volatility {
+		stats.Volatility, err = firstDifferenceRatio(numbers, stats.Mean)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.ShowVolatility = true
+	}
+	if *sdCI > 0 {
+		stats.StdDevCILower, stats.StdDevCIUpper, err = stdDevCI(stats.Variance, stats.Count, *sdCI/100.0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		stats.StdDevCILevel = *sdCI
+	}
+	if *eps > 0 {
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		freqs := valueFrequencies(sorted, *eps)
+		stats.DistinctCount = len(freqs)
+		stats.Mode, stats.Duplicates = modesAndDuplicates(freqs)
+	}
+	if *modeTol > 0 {
+		stats.ModeTolerance = *modeTol
+		stats.ClusterMode, stats.ClusterModeCount = clusterMode(numbers, *modeTol)
+	}
+	if *cdfSpark {
+		stats.CDFSparkline = generateCDFSparkline(numbers, *numBins, ramp)
+	}
+	var geoCI GeometricMeanCI
+	if *geomeanReport {
+		geoCI, err = geometricMeanCI(numbers, *ciLevel/100.0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if weights != nil {
+		stats.WeightedMedian, err = weightedMedian(numbers, weights)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		stats.HasWeights = true
+	}
+	if *targetFlag != "" {
+		stats.Target = target
+		stats.HasTarget = true
+		stats.MAPE, stats.Bias = calculateTargetError(numbers, target)
+	}
+	stats.MissingCount = missingCount
+	stats.NearConstant = isNearConstant(stats, *constThreshold)
+	if *showRanks {
+		stats.Ranks = calculateRanks(numbers)
+	}
+	if *modZScores {
+		stats.ModZScores = calculateModifiedZScores(numbers, stats.Median, stats.MAD)
+	}
+	if *outlierConsensus {
+		stats.ConsensusOutliers = consensusOutliers(stats.Outliers, stats.Median, stats.MAD, *consensusModZ)
+		stats.ConsensusModZ = *consensusModZ
+	}
+
+	exitCode := 0
+	if *failOnOutliers {
+		exitCode = outlierExitCode(stats)
+	}
+
+	if *saveBaselineFile != "" {
+		if err := saveBaseline(*saveBaselineFile, stats, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving baseline: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var baseline *Stats
+	if *checkBaselineFile != "" {
+		baseline, err = loadBaseline(*checkBaselineFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading baseline: %v\n", err)
+			os.Exit(1)
+		}
+		if _, ok := meanDrift(baseline, stats, *tolerance); !ok {
+			exitCode = baselineDriftExitCode
+		}
+	}
+
+	if *templateFlag != "" {
+		out, err := renderTemplate(*templateFlag, stats)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(out)
+		os.Exit(exitCode)
+	}
+
+	if len(fields) > 0 {
+		fmt.Print(formatFields(stats, fields))
+		os.Exit(exitCode)
+	}
+
+	if *histJSON {
+		if *histDensity {
+			for i, d := range histogramDensities(histBins) {
+				histBins[i].Density = &d
+			}
+		}
+		if err := writeHistogramJSON(os.Stdout, histBins, histData); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(exitCode)
+	}
+
+	if *decilesFlag {
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		out, err := json.Marshal(deciles(sorted, percentile))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		os.Exit(exitCode)
+	}
+
+	if *summaryJSON {
+		if err := writeSummaryJSON(os.Stdout, stats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(exitCode)
+	}
+
+	if *jsonl {
+		if err := writeJSONL(os.Stdout, stats, "", time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(exitCode)
+	}
+
+	if *excludeZerosFlag {
+		fmt.Printf("(excluded %d zero values)\n", zerosExcluded)
+		fmt.Println()
+	}
+	if *filterFlag != "" {
+		noun := "values"
+		if filteredOut == 1 {
+			noun = "value"
+		}
+		fmt.Printf("(filtered to %s: excluded %d %s)\n", *filterFlag, filteredOut, noun)
+		fmt.Println()
+	}
+	if *pctChange {
+		fmt.Printf("(percent changes: %d values → %d changes)\n", pctChangeFrom, len(numbers))
+		fmt.Println()
+	}
+	if *logTransform {
+		fmt.Println("(log-transformed, base e)")
+		fmt.Println()
+	}
+	if *logShift {
+		fmt.Printf("(log-transformed, base e, shifted: ln(x + %s))\n", formatFloat(logShiftAmount))
+		fmt.Println()
+	}
+	if *resample > 0 && resampledFrom > len(numbers) {
+		fmt.Printf("(resampled: %d → %d bucket averages)\n", resampledFrom, len(numbers))
+		fmt.Println()
+	}
+	if *detrendFlag {
+		fmt.Printf("(detrended: removed %s + %s × position)\n", formatFloat(detrendIntercept), formatFloat(detrendSlope))
+		fmt.Println()
+	}
+	if *robustStd {
+		fmt.Printf("(robust-standardized: (x - %s) / %s)\n", formatFloat(robustCenter), formatFloat(robustScale))
+		fmt.Println()
+	}
+	if *scaleFlag != "" {
+		fmt.Printf("(scaled: Min → %s, Max → %s)\n", formatFloat(scaleLo), formatFloat(scaleHi))
+		fmt.Println()
+	}
+	if *trimDatasetPct > 0 {
+		fmt.Printf("(trimmed dataset: %s%% from each tail, %d → %d values)\n", formatFloat(*trimDatasetPct), originalCount, stats.Count)
+		fmt.Println()
+	}
+	if *latency {
+		fmt.Print(formatLatencyTable(stats, numbers, percentile))
+	} else if *robust {
+		fmt.Print(formatRobustSummary(stats))
+	} else {
+		printStats(stats, opts, labelWidth)
+	}
+	if *showMeans {
+		fmt.Println("\n--- Means Comparison ---")
+		fmt.Print(formatMeansComparison(stats))
+	}
+	if *geomeanReport {
+		fmt.Println("\n--- Geometric Mean Report ---")
+		fmt.Print(formatGeometricMeanCI(geoCI, *ciLevel))
+	}
+	if *totals {
+		fmt.Println("\n--- Totals ---")
+		fmt.Print(formatTotals(stats))
+	}
+	if *countBySign {
+		fmt.Println("\n--- Sign Breakdown ---")
+		fmt.Print(formatSignBreakdown(stats))
+	}
+	if *skewTest {
+		fmt.Println("\n--- Skewness Test ---")
+		if test, err := skewnessTest(stats.Skewness, stats.Count); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Print(formatShapeTest(test, "right skewed", "left skewed", "symmetry"))
+		}
+	}
+	if *kurtTest {
+		fmt.Println("\n--- Kurtosis Test ---")
+		if test, err := kurtosisTest(stats.Kurtosis, stats.Count); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Print(formatShapeTest(test, "heavy tails", "light tails", "normal tails"))
+		}
+	}
+	if *withinSD {
+		fmt.Println("\n--- Empirical Rule ---")
+		fmt.Print(formatEmpiricalRule(stats))
+	}
+	if *normality {
+		fmt.Println("\n--- Normality Checks ---")
+		if checks, err := normalityChecks(numbers, stats); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Print(formatNormality(checks))
+		}
+	}
+	if *scoreFlag {
+		fmt.Println("\n--- Health Score ---")
+		if h, err := healthScore(numbers, stats); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Print(formatHealthScore(h, stats))
+		}
+	}
+	if *fitQuality {
+		fmt.Println("\n--- Trend Fit Quality ---")
+		if fit, err := trendFitQuality(numbers); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Print(formatTrendFit(fit))
+		}
+	}
+	if stats.HasTarget {
+		fmt.Printf("\n--- Target Comparison (T = %s) ---\n", formatFloat(stats.Target))
+		fmt.Print(formatTargetError(stats))
+	}
+	if *theoreticalPctl > 0 {
+		fmt.Printf("\n--- Theoretical Percentile ---\n")
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		fmt.Print(formatTheoreticalPercentile(*theoreticalPctl, stats, percentile(sorted, *theoreticalPctl/100.0)))
+	}
+	if *pctlCI > 0 {
+		fmt.Printf("\n--- Percentile Confidence Interval ---\n")
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		ci, err := percentileCI(sorted, *pctlCI/100.0, *ciLevel/100.0)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Print(formatPercentileCI(*pctlCI, *ciLevel, ci))
+		}
+	}
+	if *bootstrap > 0 {
+		fmt.Printf("\n--- Bootstrap Confidence Interval ---\n")
+		seed := resolveSeed(*seedFlag, numbers)
+		lower, upper := bootstrapMeanCI(numbers, *bootstrap, *ciLevel/100.0, rand.New(rand.NewPCG(seed, 0)))
+		fmt.Print(formatBootstrapCI(*ciLevel, lower, upper, *bootstrap, seed))
+	}
+	if len(rankQueries) > 0 {
+		fmt.Println("\n--- Percentile Ranks ---")
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		fmt.Print(formatPercentileRanks(sorted, rankQueries))
+	}
+	if *showWork {
+		fmt.Println("\n--- Show Work ---")
+		fmt.Print(formatShowWork(numbers, stats))
+	}
+	if *detectClipping {
+		fmt.Println("\n--- Clipping Check ---")
+		atMin, atMax := countAtExtremes(numbers, stats.Min, stats.Max)
+		fmt.Print(formatClipping(stats, atMin, atMax))
+	}
+	if *stemLeaf {
+		fmt.Println("\n--- Stem-and-Leaf Plot ---")
+		sorted := make([]float64, len(numbers))
+		copy(sorted, numbers)
+		sort.Float64s(sorted)
+		fmt.Print(formatStemLeaf(sorted))
+	}
+	if *boxPlot {
+		fmt.Println("\n--- Box Plot ---")
+		fmt.Print(formatBoxPlot(stats, withinFences(numbers, stats, *iqrMultiplier), *boxPlotWidth))
+	}
+	if *digitBiasFlag {
+		fmt.Println("\n--- Last Digit Distribution ---")
+		if b, err := digitBias(numbers); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Print(formatDigitBias(b))
+		}
+	}
+	if *histEqualFreq && histBins != nil {
+		fmt.Println("\n--- Equal-Frequency Bins ---")
+		fmt.Print(formatEqualFrequencyBins(histBins))
+	}
+	if *chunkSize > 0 {
+		fmt.Printf("\n--- Chunks (size %d) ---\n", *chunkSize)
+		fmt.Print(formatChunks(computeChunks(numbers, *chunkSize)))
+	}
+	if *showRanks {
+		fmt.Println("\n--- Ranks ---")
+		fmt.Print(formatRanks(numbers, stats.Ranks))
+	}
+	if *modZScores {
+		fmt.Println("\n--- Modified Z-Scores ---")
+		if stats.ModZScores == nil {
+			fmt.Println("N/A - MAD is 0")
+		} else {
+			fmt.Print(formatRanks(numbers, stats.ModZScores))
+		}
+	}
+	if *varianceContributors > 0 {
+		fmt.Println("\n--- Variance Contributors ---")
+		if top := topVarianceContributors(numbers, stats.Mean, *varianceContributors); top == nil {
+			fmt.Println("N/A - all values are equal")
+		} else {
+			fmt.Print(formatVarianceContributors(top))
+		}
+	}
+	if *showDupes {
+		fmt.Println("\n--- Duplicate Values ---")
+		fmt.Print(formatDuplicates(stats.Duplicates))
+	}
+	if *showSorted {
+		fmt.Println("\n--- Sorted Values ---")
+		fmt.Println(formatSortedValues(numbers, 80, *precision))
+	}
+	if *explain {
+		fmt.Println("\n--- Explanation ---")
+		fmt.Println(wrapText(explainStats(stats), 80))
+	}
+	if baseline != nil {
+		fmt.Printf("\n--- Baseline Check (%s) ---\n", *checkBaselineFile)
+		fmt.Print(formatBaselineCheck(baseline, stats, *tolerance))
+	}
+	os.Exit(exitCode)
+}
+
+// fileResult holds the statistics, or the error, for a single input in -per-file mode.
+type fileResult struct {
+	Name  string
+	Stats *Stats
+	Err   error
+}
+
+// openInput opens a named input for reading, treating "-" as stdin.
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// readInputNumbers opens a named input ("-" for stdin) and reads its numbers, also returning the
+// number of blank or invalid lines skipped.
+func readInputNumbers(name string) ([]float64, int, error) {
+	rc, err := openInput(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rc.Close()
+	return readNumbersWithMissing(rc)
+}
+
+// computePerFile reads and computes statistics for each named input concurrently. A failure in one
+// input is recorded in its result and does not affect the others. The combined statistics are
+// computed over the values of every input that was read successfully, in argument order.
+func computePerFile(names []string, open func(name string) (io.ReadCloser, error), compute func([]float64) (*Stats, error)) ([]fileResult, *Stats, error) {
+	results := make([]fileResult, len(names))
+	numbers := make([][]float64, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i].Name = name
+			rc, err := open(name)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			defer rc.Close()
+			nums, skipped, err := readNumbersWithMissing(rc)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Stats, results[i].Err = compute(nums)
+			if results[i].Err == nil {
+				results[i].Stats.MissingCount = skipped
+				numbers[i] = nums
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	var all []float64
+	missing := 0
+	for i, nums := range numbers {
+		all = append(all, nums...)
+		if nums != nil {
+			missing += results[i].Stats.MissingCount
+		}
+	}
+	if len(all) == 0 {
+		return results, nil, fmt.Errorf("no input could be read successfully")
+	}
+	combined, err := compute(all)
+	if err == nil {
+		combined.MissingCount = missing
+	}
+	return results, combined, err
+}
+
+// outlierExitCode returns the exit status for -fail-on-outliers: 2 when IQR outliers, or Z-score
+// outliers (when enabled), were found, and 0 otherwise.
+func outlierExitCode(s *Stats) int {
+	if len(s.Outliers) > 0 || len(s.ZScoreOutliers) > 0 {
+		return 2
+	}
+	return 0
+}
+
+// findOutOfRange returns the indices of the values of data below lower or above upper.
+func findOutOfRange(data []float64, lower, upper float64) []int {
+	var indices []int
+	for i, v := range data {
+		if v < lower || v > upper {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// formatViolations describes the out-of-range values at the given indices of data, listing at most
+// maxViolationsListed of them by their 1-based position in the input.
+func formatViolations(data []float64, indices []int, lower, upper float64) string {
+	noun := "values"
+	if len(indices) == 1 {
+		noun = "value"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Error: %d %s outside the expected range [%s, %s]:\n", len(indices), noun, formatFloat(lower), formatFloat(upper))
+	for i, idx := range indices {
+		if i == maxViolationsListed {
+			fmt.Fprintf(&sb, "  ... and %d more\n", len(indices)-maxViolationsListed)
+			break
+		}
+		fmt.Fprintf(&sb, "  value #%d: %s\n", idx+1, formatFloat(data[idx]))
+	}
+	return sb.String()
+}
+
+// writeValues writes numbers one per line at full precision, so they can be read back without loss.
+func writeValues(w io.Writer, numbers []float64) error {
+	bw := bufio.NewWriter(w)
+	for _, v := range numbers {
+		bw.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// detrend subtracts the least-squares line over input position (see indexTrend) from each value and
+// returns the residuals in input order, along with the slope and intercept that were removed.
+func detrend(numbers []float64) (residuals []float64, slope, intercept float64) {
+	slope, intercept = indexTrend(numbers)
+	residuals = make([]float64, len(numbers))
+	for i, v := range numbers {
+		residuals[i] = v - (intercept + slope*float64(i))
+	}
+	return residuals, slope, intercept
+}
+
+// robustStandardize transforms each value to (x - median) / (madScaleFactor * MAD), an outlier-resistant
+// analogue of the z-score, preserving order. It returns the transformed values with the median and
+// scaled MAD used, or an error when MAD is 0 because at least half the values equal the median.
+func robustStandardize(numbers []float64) (standardized []float64, median, scale float64, err error) {
+	sorted := make([]float64, len(numbers))
+	copy(sorted, numbers)
+	sort.Float64s(sorted)
+	median = calculatePercentile(sorted, 0.5)
+	scale = madScaleFactor * calculateMAD(numbers, median)
+	if scale == 0 {
+		return nil, 0, 0, fmt.Errorf("cannot robust-standardize: MAD is 0 (at least half the values equal the median)")
+	}
+	standardized = make([]float64, len(numbers))
+	for i, v := range numbers {
+		standardized[i] = (v - median) / scale
+	}
+	return standardized, median, scale, nil
+}
+
+// parseRange parses a range given as "A:B", such as "0:100" or "-1:1".
+func parseRange(s string) (lo, hi float64, err error) {
+	loStr, hiStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("'%s' is not of the form A:B", s)
+	}
+	lo, err = strconv.ParseFloat(strings.TrimSpace(loStr), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid lower bound '%s'", loStr)
+	}
+	hi, err = strconv.ParseFloat(strings.TrimSpace(hiStr), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid upper bound '%s'", hiStr)
+	}
+	return lo, hi, nil
+}
+
+// parseOpenRange parses an inclusive range given as "LO:HI" where either bound may be omitted, as in
+// "0:" or ":100"; an omitted bound is infinite.
+func parseOpenRange(s string) (lo, hi float64, err error) {
+	loStr, hiStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("'%s' is not of the form LO:HI", s)
+	}
+	lo, hi = math.Inf(-1), math.Inf(1)
+	if loStr = strings.TrimSpace(loStr); loStr != "" {
+		if lo, err = strconv.ParseFloat(loStr, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid lower bound '%s'", loStr)
+		}
+	}
+	if hiStr = strings.TrimSpace(hiStr); hiStr != "" {
+		if hi, err = strconv.ParseFloat(hiStr, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid upper bound '%s'", hiStr)
+		}
+	}
+	if lo > hi {
+		return 0, 0, fmt.Errorf("lower bound %s is greater than upper bound %s", formatFloat(lo), formatFloat(hi))
+	}
+	return lo, hi, nil
+}
+
+// filterRange returns the values of numbers within [lo, hi], preserving order, and how many were dropped.
+func filterRange(numbers []float64, lo, hi float64) (kept []float64, excluded int) {
+	kept = make([]float64, 0, len(numbers))
+	for _, v := range numbers {
+		if v >= lo && v <= hi {
+			kept = append(kept, v)
+		}
+	}
+	return kept, len(numbers) - len(kept)
+}
+
+// scaleToRange maps numbers linearly so that the minimum becomes a and the maximum becomes b,
+// preserving order. When all values are equal, every value maps to a.
+func scaleToRange(numbers []float64, a, b float64) []float64 {
+	if len(numbers) == 0 {
+		return numbers
+	}
+	minVal, maxVal := numbers[0], numbers[0]
+	for _, v := range numbers {
+		minVal = math.Min(minVal, v)
+		maxVal = math.Max(maxVal, v)
+	}
+	scaled := make([]float64, len(numbers))
+	for i, v := range numbers {
+		if maxVal == minVal {
+			scaled[i] = a
+		} else {
+			scaled[i] = a + (v-minVal)/(maxVal-minVal)*(b-a)
+		}
+	}
+	return scaled
+}
+
+// percentChanges returns the percent change between consecutive values, (x[i]-x[i-1])/x[i-1]*100,
+// a series one shorter than numbers. It returns an error for fewer than two values or when a
+// previous value is zero.
+func percentChanges(numbers []float64) ([]float64, error) {
+	if len(numbers) < 2 {
+		return nil, fmt.Errorf("percent change requires at least 2 values, got %d", len(numbers))
+	}
+	changes := make([]float64, len(numbers)-1)
+	for i := 1; i < len(numbers); i++ {
+		prev := numbers[i-1]
+		if prev == 0 {
+			return nil, fmt.Errorf("cannot compute percent change from the zero value at position %d", i)
+		}
+		changes[i-1] = (numbers[i] - prev) / prev * 100
+	}
+	return changes, nil
+}
+
+// excludeZeros returns the values of numbers that are not exactly zero, preserving order.
+func excludeZeros(numbers []float64) []float64 {
+	kept := make([]float64, 0, len(numbers))
+	for _, v := range numbers {
+		if v != 0 {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// progressReader wraps an io.Reader and calls report each time another `every` lines have been read.
+type progressReader struct {
+	r      io.Reader
+	every  int
+	lines  int
+	report func(lines int)
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	for _, b := range buf[:n] {
+		if b == '\n' {
+			p.lines++
+			if p.lines%p.every == 0 {
+				p.report(p.lines)
+			}
+		}
+	}
+	return n, err
+}
+
+// readNumbers reads floating-point numbers (one per line) from an io.Reader.
+func readNumbers(reader io.Reader) ([]float64, error) {
+	numbers, _, err := readNumbersWithMissing(reader)
+	return numbers, err
+}
+
+// readNumbersWithMissing reads floating-point numbers (one per line) from an io.Reader and also
+// returns the number of blank or invalid lines that were skipped.
+func readNumbersWithMissing(reader io.Reader) ([]float64, int, error) {
+	var numbers []float64
+	missing, err := scanNumbers(reader, func(num float64) {
+		numbers = append(numbers, num)
+	})
+	return numbers, missing, err
+}
+
+// parseFinite parses s as a number like strconv.ParseFloat, but also rejects NaN and ±Inf, which
+// ParseFloat accepts and which would poison every statistic.
+func parseFinite(s string) (float64, error) {
+	num, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		return 0, fmt.Errorf("%q is not a finite number", s)
+	}
+	return num, nil
+}
+
+// readColumnNumbers reads the column-th (1-based) delim-separated field of each line as a number.
+// Blank lines are skipped; lines with too few fields or an invalid or non-finite number are skipped
+// with a warning. It returns the number of lines skipped.
+func readColumnNumbers(reader io.Reader, column int, delim string) ([]float64, int, error) {
+	var numbers []float64
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	missing := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			missing++
+			continue // Skip empty lines
+		}
+
+		fields := strings.Split(line, delim)
+		if column > len(fields) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping line %d, column %d out of range (%d fields): '%s'\n", lineNum, column, len(fields), scanner.Text())
+			missing++
+			continue
+		}
+		num, err := parseFinite(strings.TrimSpace(fields[column-1]))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping invalid number on line %d: '%s'\n", lineNum, scanner.Text())
+			missing++
+			continue
+		}
+		numbers = append(numbers, num)
+	}
+	return numbers, missing, scanner.Err()
+}
+
+// readWeightedColumnNumbers is readColumnNumbers that also reads a weight for each value from the
+// weightColumn-th field of the same line. Lines whose weight is missing, invalid, or negative are
+// skipped with a warning, so the returned values and weights stay paired by index.
+func readWeightedColumnNumbers(reader io.Reader, column, weightColumn int, delim string) ([]float64, []float64, int, error) {
+	var numbers, weights []float64
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	missing := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			missing++
+			continue // Skip empty lines
+		}
+
+		fields := strings.Split(line, delim)
+		if max(column, weightColumn) > len(fields) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping line %d, column %d out of range (%d fields): '%s'\n", lineNum, max(column, weightColumn), len(fields), scanner.Text())
+			missing++
+			continue
+		}
+		num, err := parseFinite(strings.TrimSpace(fields[column-1]))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping invalid number on line %d: '%s'\n", lineNum, scanner.Text())
+			missing++
+			continue
+		}
+		w, err := parseFinite(strings.TrimSpace(fields[weightColumn-1]))
+		if err != nil || w < 0 {
+			fmt.Fprintf(os.Stderr, "Warning: skipping invalid weight on line %d: '%s'\n", lineNum, scanner.Text())
+			missing++
+			continue
+		}
+		numbers = append(numbers, num)
+		weights = append(weights, w)
+	}
+	return numbers, weights, missing, scanner.Err()
+}
+
+// skipLines consumes the first n lines of reader and returns a reader positioned after them, along
+// with the last skipped line (e.g. a header), trimmed of surrounding whitespace.
+func skipLines(reader io.Reader, n int) (io.Reader, string, error) {
+	br := bufio.NewReader(reader)
+	var last string
+	for i := 0; i < n; i++ {
+		line, err := br.ReadString('\n')
+		if err == io.EOF {
+			return br, strings.TrimSpace(line), nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		last = line
+	}
+	return br, strings.TrimSpace(last), nil
+}
+
+// readAllColumns parses delim-separated lines into one slice of numbers per column. The number of
+// columns is taken from the first non-blank line. Fields that are missing or not valid finite numbers
+// are skipped with a warning, so columns may end up with different counts. It also returns the number
+// of skipped fields in each column, counting a blank line as missing from every column.
+func readAllColumns(reader io.Reader, delim string) ([][]float64, []int, error) {
+	var columns [][]float64
+	var missing []int
+	blank := 0
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			blank++
+			continue // Skip empty lines
+		}
+
+		fields := strings.Split(line, delim)
+		if columns == nil {
+			columns = make([][]float64, len(fields))
+			missing = make([]int, len(fields))
+		}
+		for i := range columns {
+			if i >= len(fields) {
+				fmt.Fprintf(os.Stderr, "Warning: line %d has no column %d: '%s'\n", lineNum, i+1, scanner.Text())
+				missing[i]++
+				continue
+			}
+			num, err := parseFinite(strings.TrimSpace(fields[i]))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping invalid number in column %d on line %d: '%s'\n", i+1, lineNum, fields[i])
+				missing[i]++
+				continue
+			}
+			columns[i] = append(columns[i], num)
+		}
+	}
+	for i := range missing {
+		missing[i] += blank
+	}
+	return columns, missing, scanner.Err()
+}
+
+// columnNames labels n columns with the delim-separated names in header, falling back to
+// "Column K" (1-based) when there is no header or it has no name for a column.
+func columnNames(header, delim string, n int) []string {
+	var fields []string
+	if header != "" {
+		fields = strings.Split(header, delim)
+	}
+	names := make([]string, n)
+	for i := range names {
+		if i < len(fields) && strings.TrimSpace(fields[i]) != "" {
+			names[i] = strings.TrimSpace(fields[i])
+		} else {
+			names[i] = fmt.Sprintf("Column %d", i+1)
+		}
+	}
+	return names
+}
+
+// readExtractedNumbers reads every number embedded in the text of each line, e.g. 42.5 and 7 from
+// "latency=42.5ms count=7". Lines without numbers contribute nothing; it returns how many there were.
+func readExtractedNumbers(reader io.Reader) ([]float64, int, error) {
+	var numbers []float64
+	scanner := bufio.NewScanner(reader)
+	missing := 0
+	for scanner.Scan() {
+		found := extractNumbers(scanner.Text())
+		if len(found) == 0 {
+			missing++
+		}
+		numbers = append(numbers, found...)
+	}
+	return numbers, missing, scanner.Err()
+}
+
+// extractNumbers returns the numeric tokens found in line, in order.
+func extractNumbers(line string) []float64 {
+	var numbers []float64
+	for _, token := range numberPattern.FindAllString(line, -1) {
+		if num, err := strconv.ParseFloat(token, 64); err == nil {
+			numbers = append(numbers, num)
+		}
+	}
+	return numbers
+}
+
+// readNumbersStrippingUnits reads one number per line like readNumbersWithMissing, but first
+// removes a trailing unit suffix such as "ms" or "MB" from each value.
+func readNumbersStrippingUnits(reader io.Reader) ([]float64, int, error) {
+	var numbers []float64
+	missing, err := scanParsedNumbers(reader, parseWithUnits, func(num float64) {
+		numbers = append(numbers, num)
+	})
+	return numbers, missing, err
+}
+
+// parseWithUnits parses token as a number, retrying without its trailing alphabetic suffix
+// (e.g. "42.5ms" or "10 MB") when it does not parse as is. Units are dropped, not converted.
+func parseWithUnits(token string) (float64, error) {
+	num, err := parseFinite(token)
+	if err == nil {
+		return num, nil
+	}
+	stripped := strings.TrimSpace(strings.TrimRightFunc(token, unicode.IsLetter))
+	if stripped == "" || stripped == token {
+		return 0, err
+	}
+	return parseFinite(stripped)
+}
+
+// readSplitNumbers reads every number in the input, treating any run of characters other than
+// digits and '.' as a separator, regardless of line breaks. A '-' is kept as a sign when it
+// starts a token, so "a-1" yields -1 but "1-2" yields 1 and 2. Tokens with digits that are not
+// valid numbers, such as "1.2.3", are skipped; it returns how many.
+func readSplitNumbers(reader io.Reader) ([]float64, int, error) {
+	var numbers []float64
+	var token strings.Builder
+	missing := 0
+	flush := func() {
+		if num, err := parseFinite(token.String()); err == nil {
+			numbers = append(numbers, num)
+		} else if strings.ContainsAny(token.String(), "0123456789") {
+			missing++
+		}
+		token.Reset()
+	}
+
+	br := bufio.NewReader(reader)
+	prevNumeric := false
+	for {
+		r, _, err := br.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		numeric := (r >= '0' && r <= '9') || r == '.'
+		switch {
+		case numeric:
+			token.WriteRune(r)
+		case r == '-' && !prevNumeric:
+			token.Reset()
+			token.WriteRune(r)
+		default:
+			flush()
+		}
+		prevNumeric = numeric
+	}
+	flush()
+	return numbers, missing, nil
+}
+
+// scanNumbers reads floating-point numbers (one per line) from an io.Reader, calling fn for each
+// valid number without retaining the data. NaN and ±Inf count as invalid. It returns the number of
+// blank or invalid lines skipped.
+func scanNumbers(reader io.Reader, fn func(float64)) (int, error) {
+	return scanParsedNumbers(reader, parseFinite, fn)
+}
+
+// scanParsedNumbers is scanNumbers with a custom parser for each trimmed line. The parser is
+// expected to reject non-finite values, as parseFinite does.
+func scanParsedNumbers(reader io.Reader, parse func(string) (float64, error), fn func(float64)) (int, error) {
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	missing := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			missing++
+			continue // Skip empty lines
+		}
+
+		num, err := parse(line)
+		if err != nil {
+			// Log invalid lines but continue processing
+			fmt.Fprintf(
+				os.Stderr,
+				"Warning: skipping invalid number on line %d: '%s'\n",
+				lineNum,
+				scanner.Text(),
+			)
+			missing++
+			continue
+		}
+		fn(num)
+	}
+	return missing, scanner.Err()
+}
+
+// readKeyedNumbers reads 'key value' lines from an io.Reader and groups the values by key.
+// Blank lines are skipped, and lines without exactly a key and a valid finite number are skipped with
+// a warning. It also returns the skipped lines: those with an invalid value counted under their key,
+// and the blank or malformed ones, which belong to no key, as a separate total.
+func readKeyedNumbers(reader io.Reader) (map[string][]float64, map[string]int, int, error) {
+	groups := make(map[string][]float64)
+	missing := make(map[string]int)
+	unkeyed := 0
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			unkeyed++
+			continue // Skip empty lines
+		}
+		if len(fields) != 2 {
+			fmt.Fprintf(os.Stderr, "Warning: skipping line %d, expected 'key value': '%s'\n", lineNum, scanner.Text())
+			unkeyed++
+			continue
+		}
+		num, err := parseFinite(fields[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping invalid number on line %d: '%s'\n", lineNum, scanner.Text())
+			missing[fields[0]]++
+			continue
+		}
+		groups[fields[0]] = append(groups[fields[0]], num)
+	}
+	return groups, missing, unkeyed, scanner.Err()
+}
+
+// computeExtremes computes only Count, Min, and Max in a single pass over the stream,
+// without sorting or storing the data. When digest is not nil, each value is also added to it.
+func computeExtremes(reader io.Reader, digest *tDigest) (*Stats, error) {
+	stats := &Stats{}
+	_, err := scanNumbers(reader, func(num float64) {
+		if digest != nil {
+			digest.Add(num)
+		}
+		if stats.Count == 0 || num < stats.Min {
+			stats.Min = num
+		}
+		if stats.Count == 0 || num > stats.Max {
+			stats.Max = num
+		}
+		stats.Count++
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stats.Count == 0 {
+		return nil, fmt.Errorf("input contains no valid numbers")
+	}
+	return stats, nil
+}
+
+// sortedStream pulls numbers one at a time from a sorted input for a k-way merge, holding only
+// the current value.
+type sortedStream struct {
+	name    string
+	scanner *bufio.Scanner
+	lineNum int
+	head    float64 // smallest value not yet merged
+}
+
+// advance moves head to the next number, skipping blank and invalid lines as scanNumbers does but
+// without warnings, since mergeSortedFiles has already reported them while counting. It returns
+// false at the end of the stream, and an error if the stream is not in ascending order.
+func (s *sortedStream) advance(started bool) (bool, error) {
+	for s.scanner.Scan() {
+		s.lineNum++
+		line := strings.TrimSpace(s.scanner.Text())
+		if line == "" {
+			continue
+		}
+		num, err := parseFinite(line)
+		if err != nil {
+			continue
+		}
+		if started && num < s.head {
+			return false, fmt.Errorf("%s is not sorted: value %s on line %d is less than the preceding value %s", s.name, formatFloat(num), s.lineNum, formatFloat(s.head))
+		}
+		s.head = num
+		return true, nil
+	}
+	return false, s.scanner.Err()
+}
+
+// streamHeap is a min-heap of sorted streams ordered by their current value.
+type streamHeap []*sortedStream
+
+func (h streamHeap) Len() int           { return len(h) }
+func (h streamHeap) Less(i, j int) bool { return h[i].head < h[j].head }
+func (h streamHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *streamHeap) Push(x any)        { *h = append(*h, x.(*sortedStream)) }
+func (h *streamHeap) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// mergeSorted performs a k-way merge of sorted streams, calling fn with every value in ascending
+// order. Memory is bounded by the number of streams, not their length. names label the readers in
+// error messages.
+func mergeSorted(names []string, readers []io.Reader, fn func(float64)) error {
+	h := make(streamHeap, 0, len(readers))
+	for i, r := range readers {
+		s := &sortedStream{name: names[i], scanner: bufio.NewScanner(r)}
+		ok, err := s.advance(false)
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, s)
+		}
+	}
+	heap.Init(&h)
+	for h.Len() > 0 {
+		s := h[0]
+		fn(s.head)
+		ok, err := s.advance(true)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
+
+// mergedQuantiles returns the exact quantiles ps (0 <= p <= 1) of the merged sorted streams, which
+// hold n values in total. Only the order statistics bracketing each quantile's rank p*(n-1) are
+// kept, and percentile interpolates between that pair, so the result matches percentile applied to
+// the fully sorted data.
+func mergedQuantiles(names []string, readers []io.Reader, n int, ps []float64, percentile percentileFunc) ([]float64, error) {
+	needed := make(map[int]float64)
+	for _, p := range ps {
+		rank := p * float64(n-1)
+		needed[int(math.Floor(rank))] = 0
+		needed[int(math.Ceil(rank))] = 0
+	}
+	i := 0
+	err := mergeSorted(names, readers, func(v float64) {
+		if _, ok := needed[i]; ok {
+			needed[i] = v
+		}
+		i++
+	})
+	if err != nil {
+		return nil, err
+	}
+	if i != n {
+		return nil, fmt.Errorf("expected %d values in the merged inputs, got %d", n, i)
+	}
+
+	quantiles := make([]float64, len(ps))
+	for k, p := range ps {
+		rank := p * float64(n-1)
+		lo, hi := math.Floor(rank), math.Ceil(rank)
+		pair := []float64{needed[int(lo)], needed[int(hi)]}
+		quantiles[k] = percentile(pair, rank-lo)
+	}
+	return quantiles, nil
+}
+
+// mergeSortedFiles computes the exact quantiles ps of the sorted files names in two streaming
+// passes: one to count the values and one to merge them. It returns the total count.
+func mergeSortedFiles(names []string, open func(string) (io.ReadCloser, error), ps []float64, percentile percentileFunc) (int, []float64, error) {
+	openAll := func() ([]io.Reader, func(), error) {
+		var files []io.ReadCloser
+		closeAll := func() {
+			for _, f := range files {
+				f.Close()
+			}
+		}
+		readers := make([]io.Reader, len(names))
+		for i, name := range names {
+			f, err := open(name)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			files = append(files, f)
+			readers[i] = f
+		}
+		return readers, closeAll, nil
+	}
+
+	readers, closeAll, err := openAll()
+	if err != nil {
+		return 0, nil, err
+	}
+	n := 0
+	for i, r := range readers {
+		if _, err := scanNumbers(r, func(float64) { n++ }); err != nil {
+			closeAll()
+			return 0, nil, fmt.Errorf("reading %s: %v", names[i], err)
+		}
+	}
+	closeAll()
+	if n == 0 {
+		return 0, nil, fmt.Errorf("input contains no valid numbers")
+	}
+
+	readers, closeAll, err = openAll()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer closeAll()
+	quantiles, err := mergedQuantiles(names, readers, n, ps, percentile)
+	return n, quantiles, err
+}
+
+// formatMergedQuantiles renders the -merge-sorted results: the count, then Min, the quartiles, Max,
+// and the custom percentiles, in the order mergeSortedFiles computed them.
+func formatMergedQuantiles(n int, quantiles []float64, customPercentiles []float64) string {
+	labels := []string{"Min:", "Quartile 1 (p25):", "Median (p50):", "Quartile 3 (p75):", "Max:"}
+	for _, p := range customPercentiles {
+		labels = append(labels, fmt.Sprintf("Percentile (p%s):", formatFloat(p)))
+	}
+	labelWidth := len("Quartile 1 (p25):") + 2
+	for _, l := range labels {
+		labelWidth = max(labelWidth, len(l)+2)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%d\n", padLabel("Count:", labelWidth), n)
+	for i, l := range labels {
+		fmt.Fprintf(&sb, "%s%s\n", padLabel(l, labelWidth), formatFloat(quantiles[i]))
+	}
+	return sb.String()
+}
+
+// tDigestCompression is the t-digest compression used by -approx-quantiles. Larger values keep
+// more centroids (about compression/2 after compressing), trading memory for accuracy.
+const tDigestCompression = 100
+
+// centroid is a cluster of values in a t-digest, summarized by their mean and count.
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// tDigest is a merging t-digest (Dunning): a bounded-size sketch of a stream from which
+// quantiles can be estimated. Centroids near the tails are kept small, so extreme quantiles
+// stay accurate while the middle of the distribution is summarized more coarsely.
+type tDigest struct {
+	compression float64
+	centroids   []centroid // sorted by mean
+	buffer      []centroid // values added since the last compression
+	count       float64
+	min, max    float64
+}
+
+// newTDigest returns an empty t-digest with the given compression.
+func newTDigest(compression float64) *tDigest {
+	return &tDigest{compression: compression}
+}
+
+// Add adds a value to the digest, compressing when the buffer fills.
+func (d *tDigest) Add(x float64) {
+	if d.count == 0 || x < d.min {
+		d.min = x
+	}
+	if d.count == 0 || x > d.max {
+		d.max = x
+	}
+	d.count++
+	d.buffer = append(d.buffer, centroid{mean: x, weight: 1})
+	if len(d.buffer) >= int(5*d.compression) {
+		d.compress()
+	}
+}
+
+// scale is the t-digest k1 scale function. Adjacent centroids may merge only while the merged
+// cluster spans at most 1 unit of k, which keeps clusters near q=0 and q=1 small.
+func (d *tDigest) scale(q float64) float64 {
+	return d.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// compress merges the buffered values into the centroids.
+func (d *tDigest) compress() {
+	if len(d.buffer) == 0 {
+		return
+	}
+	all := append(d.centroids, d.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(all))
+	cur := all[0]
+	qLeft := 0.0
+	for _, c := range all[1:] {
+		qRight := qLeft + (cur.weight+c.weight)/d.count
+		if d.scale(qRight)-d.scale(qLeft) <= 1 {
+			cur.mean += (c.mean - cur.mean) * c.weight / (cur.weight + c.weight)
+			cur.weight += c.weight
+			continue
+		}
+		merged = append(merged, cur)
+		qLeft += cur.weight / d.count
+		cur = c
+	}
+	d.centroids = append(merged, cur)
+	d.buffer = d.buffer[:0]
+}
+
+// Centroids returns the number of centroids after compressing.
+func (d *tDigest) Centroids() int {
+	d.compress()
+	return len(d.centroids)
+}
+
+// Quantile estimates the p-th quantile (0-1) by interpolating between centroid centers, using the
+// exact Min and Max at the ends. It returns 0 for an empty digest.
+func (d *tDigest) Quantile(p float64) float64 {
+	d.compress()
+	if d.count == 0 {
+		return 0
+	}
+	target := p * d.count
+	cum := 0.0
+	prevMean, prevCenter := d.min, 0.0
+	for _, c := range d.centroids {
+		center := cum + c.weight/2
+		if target < center {
+			return prevMean + (c.mean-prevMean)*(target-prevCenter)/(center-prevCenter)
+		}
+		prevMean, prevCenter = c.mean, center
+		cum += c.weight
+	}
+	if d.count == prevCenter {
+		return d.max
+	}
+	return prevMean + (d.max-prevMean)*(target-prevCenter)/(d.count-prevCenter)
+}
+
+// applyLogTransform applies natural log to all values, returning an error if any value is <= 0.
+func applyLogTransform(numbers []float64) ([]float64, error) {
+	result := make([]float64, len(numbers))
+	for i, v := range numbers {
+		if v <= 0 {
+			return nil, fmt.Errorf("log transform requires all positive values, but got %v", v)
+		}
+		result[i] = math.Log(v)
+	}
+	return result, nil
+}
+
+// applyShiftedLogTransform applies ln(x - min + 1) to all values so that the smallest value maps to 0.
+// It returns the transformed values and the shift amount (1 - min) that was added before taking the log.
+func applyShiftedLogTransform(numbers []float64) ([]float64, float64) {
+	minVal := numbers[0]
+	for _, v := range numbers {
+		if v < minVal {
+			minVal = v
+		}
+	}
+	shift := 1 - minVal
+	result := make([]float64, len(numbers))
+	for i, v := range numbers {
+		result[i] = math.Log(v + shift)
+	}
+	return result, shift
+}
+
+// StatsOptions holds the optional settings of computeStats. The zero value disables each optional
+// statistic and uses the default histogram ramp and linear percentile interpolation.
+type StatsOptions struct {
+	EMASpan      int            // EMA span (0 = disabled)
+	TrimRangePct float64        // trimmed range percentage (0 = disabled)
+	Presorted    bool           // data is already sorted; validate instead of sorting (-sorted)
+	Ramp         []rune         // histogram and trendline characters (nil = defaultRamp)
+	Percentile   percentileFunc // percentile interpolation method (nil = calculatePercentile)
+}
+
+// computeStats calculates all the desired statistics for a slice of numbers.
+func computeStats(data []float64, customPercentiles []float64, iqrMultiplier float64, numBins int, zScoreThreshold float64, trimPct float64, opts StatsOptions) (*Stats, error) {
+	ramp, percentile := opts.Ramp, opts.Percentile
+	if ramp == nil {
+		ramp = defaultRamp
+	}
+	if percentile == nil {
+		percentile = calculatePercentile
+	}
+	count := len(data)
+	if count == 0 {
+		return nil, fmt.Errorf("input contains no valid numbers")
+	}
+
+	// Create a sorted copy for calculations that require it (median, quartiles).
+	// Pre-sorted input is only validated, which is O(n) instead of O(n log n).
+	var sortedData []float64
+	if opts.Presorted {
+		for i := 1; i < count; i++ {
+			if data[i] < data[i-1] {
+				return nil, fmt.Errorf("input is not sorted: value %s at position %d is less than the preceding value %s", formatFloat(data[i]), i+1, formatFloat(data[i-1]))
+			}
+		}
+		sortedData = data
+	} else {
+		sortedData = make([]float64, count)
+		copy(sortedData, data)
+		sort.Float64s(sortedData)
+	}
+
+	// --- Basic Stats ---
+	stats := &Stats{
+		Count: count,
+		Min:   sortedData[0],
+		Max:   sortedData[count-1],
+	}
+
+	// --- Mean ---
+	var sum float64
+	for _, v := range data {
+		sum += v
+	}
+	stats.Sum = sum
+	stats.Mean = sum / float64(count)
+
+	// --- Trend slope over input position ---
+	stats.TrendSlope, _ = indexTrend(data)
+
+	// --- Counts above, below, and at the mean ---
+	for _, v := range data {
+		switch {
+		case v > stats.Mean:
+			stats.AboveMean++
+		case v < stats.Mean:
+			stats.BelowMean++
+		default:
+			stats.AtMean++
+		}
+	}
+
+	// --- Geometric and Harmonic Means (positive data only) ---
+	stats.GeometricMean, stats.HarmonicMean, stats.PositiveMeans = calculatePositiveMeans(data)
+
+	// --- Trimmed Mean ---
+	if trimPct > 0 {
+		trimmedMean, err := calculateTrimmedMean(sortedData, trimPct, trimPct)
+		if err != nil {
+			return nil, err
+		}
+		stats.TrimmedMean = trimmedMean
+		stats.TrimmedMeanPct = trimPct
+	}
+
+	// --- Root Mean Square ---
+	var sumOfRawSquares float64
+	for _, v := range data {
+		sumOfRawSquares += v * v
+	}
+	stats.RMS = math.Sqrt(sumOfRawSquares / float64(count))
+
+	// --- Variance and Standard Deviation ---
+	if count > 1 {
+		var sumOfSquares float64
+		for _, v := range data {
+			sumOfSquares += math.Pow(v-stats.Mean, 2)
+		}
+		// Using sample variance (N-1), which is more common.
+		stats.Variance = sumOfSquares / float64(count-1)
+		stats.StdDev = math.Sqrt(stats.Variance)
+	}
+
+	// --- Median, Q1, Q3, P1, P5, P10, P95, P99 (Percentiles) ---
+	stats.Median = percentile(sortedData, 0.50)
+	stats.Q1 = percentile(sortedData, 0.25)
+	stats.Q3 = percentile(sortedData, 0.75)
+	stats.P1 = percentile(sortedData, 0.01)
+	stats.P5 = percentile(sortedData, 0.05)
+	stats.P10 = percentile(sortedData, 0.10)
+	stats.P95 = percentile(sortedData, 0.95)
+	stats.P99 = percentile(sortedData, 0.99)
+
+	// --- Custom Percentiles ---
+	if len(customPercentiles) > 0 {
+		stats.CustomPercentiles = make(map[float64]float64)
+		for _, p := range customPercentiles {
+			stats.CustomPercentiles[p] = percentile(sortedData, p/100.0)
+		}
+	}
+
+	// --- IQR ---
+	stats.IQR = stats.Q3 - stats.Q1
+	stats.Trimean = (stats.Q1 + 2*stats.Median + stats.Q3) / 4
+
+	// --- Trimmed Range ---
+	if opts.TrimRangePct > 0 {
+		stats.TrimmedRange = percentile(sortedData, 1-opts.TrimRangePct/100.0) - percentile(sortedData, opts.TrimRangePct/100.0)
+		stats.TrimmedRangePct = opts.TrimRangePct
+	}
+
+	// --- MAD (Median Absolute Deviation) ---
+	stats.MAD = calculateMAD(data, stats.Median)
+	stats.ScaledMAD = madScaleFactor * stats.MAD
+
+	// --- Mode and duplicates (exact equality) ---
+	freqs := valueFrequencies(sortedData, 0)
+	stats.DistinctCount = len(freqs)
+	stats.Mode, stats.Duplicates = modesAndDuplicates(freqs)
+
+	// --- Outliers (using the k * IQR rule) ---
+	lowerBound := stats.Q1 - iqrMultiplier*stats.IQR
+	upperBound := stats.Q3 + iqrMultiplier*stats.IQR
+
+	extremeLower := stats.Q1 - extremeOutlierMultiplier*stats.IQR
+	extremeUpper := stats.Q3 + extremeOutlierMultiplier*stats.IQR
+
+	for _, v := range data {
+		if v < lowerBound || v > upperBound {
+			stats.Outliers = append(stats.Outliers, v)
+			if v < extremeLower || v > extremeUpper {
+				stats.ExtremeOutliers = append(stats.ExtremeOutliers, v)
+			} else {
+				stats.MildOutliers = append(stats.MildOutliers, v)
+			}
+		}
+	}
+	sort.Float64s(stats.Outliers) // For consistent output
+	sort.Float64s(stats.MildOutliers)
+	sort.Float64s(stats.ExtremeOutliers)
+
+	// --- Z-Score Outliers ---
+	if zScoreThreshold > 0 && stats.StdDev > 0 {
+		stats.ZScoreThreshold = zScoreThreshold
+		for _, v := range data {
+			z := math.Abs((v - stats.Mean) / stats.StdDev)
+			if z > zScoreThreshold {
+				stats.ZScoreOutliers = append(stats.ZScoreOutliers, v)
+			}
+		}
+		sort.Float64s(stats.ZScoreOutliers)
+	}
+
+	// --- Skewness (formal calculation) ---
+	stats.Skewness = calculateSkewness(data, stats.Mean, stats.StdDev)
+
+	// --- Kurtosis (excess kurtosis) ---
+	stats.Kurtosis = calculateKurtosis(data, stats.Mean, stats.StdDev)
+
+	// --- Sign breakdown (also flags negative data) ---
+	for _, v := range data {
+		switch {
+		case v > 0:
+			stats.PositiveCount++
+			stats.PositiveSum += v
+		case v < 0:
+			stats.NegativeCount++
+			stats.NegativeSum += v
+		default:
+			stats.ZeroCount++
+		}
+	}
+	stats.SumAbs = stats.PositiveSum - stats.NegativeSum
+	stats.HasNegativeData = stats.NegativeCount > 0
+
+	// --- Percent within 1, 2, and 3 standard deviations ---
+	stats.WithinOneSD, stats.WithinTwoSD, stats.WithinThreeSD = percentWithinSD(data, stats.Mean, stats.StdDev)
+
+	// --- Coefficient of Variation ---
+	if math.Abs(stats.Mean) < 1e-10 {
+		stats.CVValid = false
+	} else {
+		stats.CVValid = true
+		stats.CV = (stats.StdDev / math.Abs(stats.Mean)) * 100
+	}
+
+	// --- EMA ---
+	if opts.EMASpan >= 2 {
+		stats.EMA = calculateEMA(data, opts.EMASpan)
+		stats.EMASpan = opts.EMASpan
+	}
+
+	// --- Histogram ---
+	stats.Histogram = generateHistogram(sortedData, numBins, ramp)
+
+	// --- Trendline ---
+	stats.Trendline = generateTrendline(data, numBins, ramp)
+
+	return stats, nil
+}
+
+// MergeStats combines partial statistics computed on two disjoint chunks of a dataset.
+// Count, Sum, Mean, Variance, StdDev, CV, Min, and Max are combined exactly, using the
+// parallel variance formula of Chan et al. Order statistics (median, quartiles, percentiles),
+// mode, skewness, kurtosis, and outliers cannot be merged from summaries, so they are left
+// empty and the result is marked as Merged.
+func MergeStats(a, b *Stats) *Stats {
+	if a == nil || a.Count == 0 {
+		return b
+	}
+	if b == nil || b.Count == 0 {
+		return a
+	}
+
+	n := a.Count + b.Count
+	na, nb := float64(a.Count), float64(b.Count)
+	delta := b.Mean - a.Mean
+
+	merged := &Stats{
+		Count:           n,
+		Sum:             a.Sum + b.Sum,
+		Mean:            a.Mean + delta*nb/float64(n),
+		Min:             math.Min(a.Min, b.Min),
+		Max:             math.Max(a.Max, b.Max),
+		HasNegativeData: a.HasNegativeData || b.HasNegativeData,
+		PositiveCount:   a.PositiveCount + b.PositiveCount,
+		NegativeCount:   a.NegativeCount + b.NegativeCount,
+		ZeroCount:       a.ZeroCount + b.ZeroCount,
+		PositiveSum:     a.PositiveSum + b.PositiveSum,
+		NegativeSum:     a.NegativeSum + b.NegativeSum,
+		SumAbs:          a.SumAbs + b.SumAbs,
+		Merged:          true,
+	}
+
+	if n > 1 {
+		// Sum of squared deviations (M2) for each part, recovered from its sample variance
+		m2a := a.Variance * (na - 1)
+		m2b := b.Variance * (nb - 1)
+		m2 := m2a + m2b + delta*delta*na*nb/float64(n)
+		merged.Variance = m2 / float64(n-1)
+		merged.StdDev = math.Sqrt(merged.Variance)
+	}
+
+	if math.Abs(merged.Mean) >= 1e-10 {
+		merged.CVValid = true
+		merged.CV = (merged.StdDev / math.Abs(merged.Mean)) * 100
+	}
+	return merged
+}
+
+// HistogramBin is one bin of the histogram: values in [Lower, Upper), with the last bin closed.
+type HistogramBin struct {
+	Lower   float64  `json:"lower"`
+	Upper   float64  `json:"upper"`
+	Count   int      `json:"count"`
+	Weight  *float64 `json:"weight,omitempty"`  // summed weights, set only for weighted bins; see computeWeightedHistogramBins
+	Density *float64 `json:"density,omitempty"` // set by -hist-density; see histogramDensities
+}
+
+// computeHistogramBins divides the range of sorted data into numBins equal-width bins and counts the
+// values in each. It returns nil when there are fewer than two values or all values are equal.
+func computeHistogramBins(sortedData []float64, numBins int) []HistogramBin {
+	n := len(sortedData)
+	if n < 2 {
+		return nil
+	}
+	minVal := sortedData[0]
+	maxVal := sortedData[n-1]
+	if minVal == maxVal {
+		return nil
+	}
+
+	bins, binWidth := linearBins(minVal, maxVal, numBins)
+	countBins(bins, sortedData, nil, minVal, binWidth)
+	return bins
+}
+
+// computeWeightedHistogramBins is computeHistogramBins for values, in any order, paired with
+// non-negative weights: each bin also sums the weights of its values in Weight, and renderHistogram
+// then scales the bars by weight instead of count.
+func computeWeightedHistogramBins(values, weights []float64, numBins int) []HistogramBin {
+	if len(values) < 2 {
+		return nil
+	}
+	minVal, maxVal := values[0], values[0]
+	for _, v := range values {
+		minVal = math.Min(minVal, v)
+		maxVal = math.Max(maxVal, v)
+	}
+	if minVal == maxVal {
+		return nil
+	}
+
+	bins, binWidth := linearBins(minVal, maxVal, numBins)
+	countBins(bins, values, weights, minVal, binWidth)
+	return bins
+}
+
+// computeEqualFrequencyBins divides sorted data into numBins bins holding the same number of values,
+// give or take one, so the bins are narrow where the data is dense and wide where it is sparse. Each
+// bin runs from its smallest value to the next bin's smallest value (the last bin ends at the
+// maximum), and tied values may be split across adjacent bins. A bin of tied values would have zero
+// width, so it is merged into the next bin (or the last one into the previous bin), and heavily tied
+// data gets fewer, fuller bins. It returns nil when there are fewer than two values or all values are
+// equal; numBins is reduced to the number of values if needed.
+func computeEqualFrequencyBins(sortedData []float64, numBins int) []HistogramBin {
+	n := len(sortedData)
+	if n < 2 || sortedData[0] == sortedData[n-1] {
+		return nil
+	}
+	numBins = min(numBins, n)
+	var bins []HistogramBin
+	pending := 0 // values of zero-width bins waiting to be merged into the next bin
+	for i := 0; i < numBins; i++ {
+		start, end := i*n/numBins, (i+1)*n/numBins
+		upper := sortedData[n-1]
+		if end < n {
+			upper = sortedData[end]
+		}
+		if upper == sortedData[start] {
+			pending += end - start
+			continue
+		}
+		bins = append(bins, HistogramBin{Lower: sortedData[start-pending], Upper: upper, Count: pending + end - start})
+		pending = 0
+	}
+	if pending > 0 {
+		bins[len(bins)-1].Count += pending
+	}
+	return bins
+}
+
+// formatEqualFrequencyBins lists each bin's range, width, and count, since equal-frequency bins vary in width.
+func formatEqualFrequencyBins(bins []HistogramBin) string {
+	rows := [][]string{{"Lower", "Upper", "Width", "Count"}}
+	for _, b := range bins {
+		rows = append(rows, []string{formatFloat(b.Lower), formatFloat(b.Upper), formatFloat(b.Upper - b.Lower), strconv.Itoa(b.Count)})
+	}
+	return formatTable(rows)
+}
+
+// linearBins returns numBins empty equal-width bins spanning [minVal, maxVal] and their width.
+func linearBins(minVal, maxVal float64, numBins int) ([]HistogramBin, float64) {
+	binWidth := (maxVal - minVal) / float64(numBins)
+	bins := make([]HistogramBin, numBins)
+	for i := range bins {
+		bins[i].Lower = minVal + float64(i)*binWidth
+		bins[i].Upper = minVal + float64(i+1)*binWidth
+	}
+	bins[numBins-1].Upper = maxVal
+	return bins, binWidth
+}
+
+// computeLogHistogramBins divides the range of sorted data into numBins bins whose edges are evenly
+// spaced on a log scale, so each bin spans the same ratio, and counts the values in each. All values
+// must be positive. It returns nil when there are fewer than two values or all values are equal.
+func computeLogHistogramBins(sortedData []float64, numBins int) ([]HistogramBin, error) {
+	n := len(sortedData)
+	if n > 0 && sortedData[0] <= 0 {
+		return nil, fmt.Errorf("log-scale histogram requires all values to be positive, found %s", formatFloat(sortedData[0]))
+	}
+	if n < 2 || sortedData[0] == sortedData[n-1] {
+		return nil, nil
+	}
+
+	logs := make([]float64, n)
+	for i, v := range sortedData {
+		logs[i] = math.Log(v)
+	}
+	logMin := logs[0]
+	logWidth := (logs[n-1] - logMin) / float64(numBins)
+	bins := make([]HistogramBin, numBins)
+	for i := range bins {
+		bins[i].Lower = math.Exp(logMin + float64(i)*logWidth)
+		bins[i].Upper = math.Exp(logMin + float64(i+1)*logWidth)
+	}
+	bins[0].Lower = sortedData[0]
+	bins[numBins-1].Upper = sortedData[n-1]
+	countBins(bins, logs, nil, logMin, logWidth)
+	return bins, nil
+}
+
+// countBins increments the count of the bin each value falls into, for bins of the given width
+// starting at lower. Values at the top edge are counted in the last bin. When weights is not nil,
+// every bin gets a Weight, and each value's weight is added to its bin's Weight.
+func countBins(bins []HistogramBin, values, weights []float64, lower, width float64) {
+	if weights != nil {
+		for i := range bins {
+			bins[i].Weight = new(float64)
+		}
+	}
+	for i, v := range values {
+		idx := int((v - lower) / width)
+		if idx >= len(bins) {
+			idx = len(bins) - 1
+		}
+		bins[idx].Count++
+		if weights != nil {
+			*bins[idx].Weight += weights[i]
+		}
+	}
+}
+
+// clusterMode finds the largest set of values all within tol of each other, that is the most
+// populous window of sorted values whose max - min is at most tol, and returns its mean and size.
+// Ties go to the lowest window.
+func clusterMode(data []float64, tol float64) (float64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+
+	bestStart, bestLen := 0, 1
+	start := 0
+	for end := range sorted {
+		for sorted[end]-sorted[start] > tol {
+			start++
+		}
+		if end-start+1 > bestLen {
+			bestStart, bestLen = start, end-start+1
+		}
+	}
+	var sum float64
+	for _, v := range sorted[bestStart : bestStart+bestLen] {
+		sum += v
+	}
+	return sum / float64(bestLen), bestLen
+}
+
+// modalBin returns the bin with the highest count, preferring the lowest bin on ties,
+// or nil when there are no bins.
+func modalBin(bins []HistogramBin) *HistogramBin {
+	if len(bins) == 0 {
+		return nil
+	}
+	best := 0
+	for i, b := range bins {
+		if b.Count > bins[best].Count {
+			best = i
+		}
+	}
+	bin := bins[best]
+	return &bin
+}
+
+// binIndex returns the index of the bin containing v, or -1 when v is below the first bin.
+// Values at or above the top edge belong to the last bin.
+func binIndex(bins []HistogramBin, v float64) int {
+	if len(bins) == 0 || v < bins[0].Lower {
+		return -1
+	}
+	for i, b := range bins {
+		if v < b.Upper {
+			return i
+		}
+	}
+	return len(bins) - 1
+}
+
+// quartileMarkers returns a line with a '|' under each histogram bin holding one of the given
+// values (e.g. Q1, median, and Q3), aligned with the histogram characters.
+func quartileMarkers(bins []HistogramBin, values ...float64) string {
+	if len(bins) == 0 {
+		return ""
+	}
+	line := []rune(strings.Repeat(" ", len(bins)))
+	for _, v := range values {
+		if idx := binIndex(bins, v); idx >= 0 {
+			line[idx] = '|'
+		}
+	}
+	return strings.TrimRight(string(line), " ")
+}
+
+// generateHistogram creates a Unicode histogram from sorted data using the given character ramp.
+func generateHistogram(sortedData []float64, numBins int, ramp []rune) string {
+	return renderHistogram(computeHistogramBins(sortedData, numBins), ramp)
+}
+
+// renderHistogram draws one character per bin, scaled so the fullest bin uses the top of the ramp.
+// Fullness is the density when set, the summed weight for bins from computeWeightedHistogramBins,
+// or otherwise the count.
+func renderHistogram(bins []HistogramBin, ramp []rune) string {
+	if bins == nil {
+		return ""
+	}
+
+	// Bins with a density (equal-frequency bins) scale by it, and weighted bins by their summed
+	// weight, instead of by their count
+	height := func(b HistogramBin) float64 { return float64(b.Count) }
+	for _, b := range bins {
+		if b.Density != nil {
+			height = func(b HistogramBin) float64 { return *b.Density }
+			break
+		}
+		if b.Weight != nil {
+			height = func(b HistogramBin) float64 { return *b.Weight }
+			break
+		}
+	}
+	maxHeight := 0.0
+	for _, b := range bins {
+		maxHeight = math.Max(maxHeight, height(b))
+	}
+
+	top := float64(len(ramp) - 1)
+	runes := make([]rune, len(bins))
+	for i, b := range bins {
+		if height(b) == 0 {
+			runes[i] = ramp[0]
+		} else {
+			runes[i] = ramp[int(height(b)*top/maxHeight)]
+		}
+	}
+	return string(runes)
+}
+
+// renderInterpolatedHistogram is a smoother alternative to renderHistogram. Each bar height is a linear
+// blend of its own count (weight 2) and its neighbours' counts (weight 1 each), and is rounded to the
+// nearest ramp level rather than truncated, so adjacent bars step more gradually. The output has the
+// same number of characters as there are bins.
+func renderInterpolatedHistogram(bins []HistogramBin, ramp []rune) string {
+	if bins == nil {
+		return ""
+	}
+
+	heights := make([]float64, len(bins))
+	maxHeight := 0.0
+	for i, b := range bins {
+		sum, weight := 2*float64(b.Count), 2.0
+		if i > 0 {
+			sum += float64(bins[i-1].Count)
+			weight++
+		}
+		if i < len(bins)-1 {
+			sum += float64(bins[i+1].Count)
+			weight++
+		}
+		heights[i] = sum / weight
+		maxHeight = math.Max(maxHeight, heights[i])
+	}
+
+	top := float64(len(ramp) - 1)
+	runes := make([]rune, len(bins))
+	for i, h := range heights {
+		level := 0
+		if maxHeight > 0 {
+			level = int(math.Round(h / maxHeight * top))
+		}
+		runes[i] = ramp[level]
+	}
+	return string(runes)
+}
+
+// generateCDFSparkline creates a Unicode sparkline of the empirical cumulative distribution using the
+// given character ramp. Each character is the fraction of values at or below the upper edge of its
+// bin, so the sparkline never decreases and always ends at the top of the ramp.
+func generateCDFSparkline(data []float64, numBins int, ramp []rune) string {
+	n := len(data)
+	if n < 2 {
+		return ""
+	}
+	sortedData := make([]float64, n)
+	copy(sortedData, data)
+	sort.Float64s(sortedData)
+	minVal := sortedData[0]
+	maxVal := sortedData[n-1]
+	if minVal == maxVal {
+		return ""
+	}
+
+	binWidth := (maxVal - minVal) / float64(numBins)
+	top := len(ramp) - 1
+	runes := make([]rune, numBins)
+	idx := 0
+	for i := range runes {
+		edge := minVal + float64(i+1)*binWidth
+		if i == numBins-1 {
+			edge = maxVal
+		}
+		for idx < n && sortedData[idx] <= edge {
+			idx++
+		}
+		runes[i] = ramp[idx*top/n]
+	}
+	return string(runes)
+}
+
+// withinFences returns the values of data inside the IQR fences (Q1 - k*IQR, Q3 + k*IQR), i.e. the
+// non-outliers, in sorted order.
+func withinFences(data []float64, s *Stats, iqrMultiplier float64) []float64 {
+	lowerBound := s.Q1 - iqrMultiplier*s.IQR
+	upperBound := s.Q3 + iqrMultiplier*s.IQR
+	var kept []float64
+	for _, v := range data {
+		if v >= lowerBound && v <= upperBound {
+			kept = append(kept, v)
+		}
+	}
+	sort.Float64s(kept)
+	return kept
+}
+
+// bucketAverages divides data into numBuckets consecutive chunks using floating-point boundaries
+// and returns the average of each, preserving input order. numBuckets must not exceed len(data).
+func bucketAverages(data []float64, numBuckets int) []float64 {
+	n := len(data)
+	step := float64(n) / float64(numBuckets)
+	averages := make([]float64, numBuckets)
+	for i := 0; i < numBuckets; i++ {
+		start := int(math.Round(float64(i) * step))
+		end := int(math.Round(float64(i+1) * step))
+		if end > n {
+			end = n
+		}
+		if end <= start {
+			end = start + 1
+		}
+		var sum float64
+		for j := start; j < end; j++ {
+			sum += data[j]
+		}
+		averages[i] = sum / float64(end-start)
+	}
+	return averages
+}
+
+// resampleSeries downsamples data to k values by averaging consecutive groups, preserving order.
+// Data with k or fewer values is returned unchanged.
+func resampleSeries(data []float64, k int) []float64 {
+	if len(data) <= k {
+		return data
+	}
+	return bucketAverages(data, k)
+}
+
+// generateTrendline creates a Unicode trendline from data in its original input order using the given character ramp.
+func generateTrendline(data []float64, numBins int, ramp []rune) string {
+	n := len(data)
+	if n < 2 {
+		return ""
+	}
+
+	minVal := data[0]
+	maxVal := data[0]
+	for _, v := range data {
+		if v < minVal {
+			minVal = v
+		}
+		if v > maxVal {
+			maxVal = v
+		}
+	}
+	if minVal == maxVal {
+		return ""
+	}
+
+	// Cap numBins to data length
+	if numBins > n {
+		numBins = n
+	}
+
+	averages := bucketAverages(data, numBins)
+
+	top := len(ramp) - 1
+	runes := make([]rune, numBins)
+	for i, avg := range averages {
+		normalized := (avg - minVal) / (maxVal - minVal)
+		level := int(math.Round(normalized * float64(top)))
+		if level < 0 {
+			level = 0
+		}
+		if level > top {
+			level = top
+		}
+		runes[i] = ramp[level]
+	}
+	return string(runes)
+}
+
+// computeChunks partitions data into consecutive chunks of size values and summarizes each one.
+// The last chunk may be smaller than size.
+func computeChunks(data []float64, size int) []ChunkSummary {
+	var chunks []ChunkSummary
+	for start := 0; start < len(data); start += size {
+		end := min(start+size, len(data))
+		chunk := ChunkSummary{Start: start + 1, End: end, Min: data[start], Max: data[start]}
+		var sum float64
+		for _, v := range data[start:end] {
+			sum += v
+			chunk.Min = math.Min(chunk.Min, v)
+			chunk.Max = math.Max(chunk.Max, v)
+		}
+		chunk.Mean = sum / float64(end-start)
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// formatChunks renders chunk summaries as an aligned table.
+func formatChunks(chunks []ChunkSummary) string {
+	rows := [][]string{{"Values", "Mean", "Min", "Max"}}
+	for _, c := range chunks {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d-%d", c.Start, c.End),
+			formatFloat(c.Mean),
+			formatFloat(c.Min),
+			formatFloat(c.Max),
+		})
+	}
+	return formatTable(rows)
+}
+
+// baselineStats are the statistics compared by the -baseline report, in display order.
+var baselineStats = []struct {
+	Label string
+	Value func(s *Stats) float64
+}{
+	{"Count", func(s *Stats) float64 { return float64(s.Count) }},
+	{"Mean", func(s *Stats) float64 { return s.Mean }},
+	{"Median", func(s *Stats) float64 { return s.Median }},
+	{"Std Deviation", func(s *Stats) float64 { return s.StdDev }},
+	{"Min", func(s *Stats) float64 { return s.Min }},
+	{"Max", func(s *Stats) float64 { return s.Max }},
+	{"Q1 (p25)", func(s *Stats) float64 { return s.Q1 }},
+	{"Q3 (p75)", func(s *Stats) float64 { return s.Q3 }},
+	{"IQR", func(s *Stats) float64 { return s.IQR }},
+	{"p95", func(s *Stats) float64 { return s.P95 }},
+	{"p99", func(s *Stats) float64 { return s.P99 }},
+}
+
+// formatBaselineDiff renders a side-by-side table of each statistic for a baseline and a current run,
+// with the delta (current - baseline) and the percent change relative to the baseline.
+func formatBaselineDiff(baseline, current *Stats) string {
+	rows := [][]string{{"Statistic", "Baseline", "Current", "Delta", "Change"}}
+	for _, bs := range baselineStats {
+		before, after := bs.Value(baseline), bs.Value(current)
+		delta := after - before
+		change := "N/A"
+		if before != 0 {
+			change = formatSigned(delta/math.Abs(before)*100) + "%"
+		}
+		rows = append(rows, []string{bs.Label, formatFloat(before), formatFloat(after), formatSigned(delta), change})
+	}
+	return formatTable(rows)
+}
+
+// PercentileComparison is one percentile of two groups and the difference B - A.
+type PercentileComparison struct {
+	P     float64 // percentile, 0-100
+	A, B  float64
+	Delta float64
+}
+
+// comparePercentiles computes each percentile ps (0-100) of the sorted groups a and b.
+func comparePercentiles(a, b []float64, ps []float64, percentile percentileFunc) []PercentileComparison {
+	rows := make([]PercentileComparison, len(ps))
+	for i, p := range ps {
+		va, vb := percentile(a, p/100), percentile(b, p/100)
+		rows[i] = PercentileComparison{P: p, A: va, B: vb, Delta: vb - va}
+	}
+	return rows
+}
+
+// formatPercentileComparison renders the -compare-percentiles table, with the delta also as a percent of A.
+func formatPercentileComparison(rows []PercentileComparison) string {
+	table := [][]string{{"Percentile", "A", "B", "Delta", "Change"}}
+	for _, r := range rows {
+		change := "N/A"
+		if r.A != 0 {
+			change = formatSigned(r.Delta/math.Abs(r.A)*100) + "%"
+		}
+		table = append(table, []string{"P" + formatFloat(r.P), formatFloat(r.A), formatFloat(r.B), formatSigned(r.Delta), change})
+	}
+	return formatTable(table)
+}
+
+// cohensD computes Cohen's d effect size of the difference between two groups' means,
+// (mean(b) - mean(a)) / pooled standard deviation, where the pooled variance weights each group's
+// sample variance by its degrees of freedom. Each group needs at least 2 values.
+func cohensD(a, b *Stats) (float64, error) {
+	if a.Count < 2 || b.Count < 2 {
+		return 0, fmt.Errorf("each group needs at least 2 values, got %d and %d", a.Count, b.Count)
+	}
+	pooledVar := (float64(a.Count-1)*a.Variance + float64(b.Count-1)*b.Variance) / float64(a.Count+b.Count-2)
+	if pooledVar == 0 {
+		return 0, fmt.Errorf("pooled standard deviation is 0")
+	}
+	return (b.Mean - a.Mean) / math.Sqrt(pooledVar), nil
+}
+
+// interpretCohensD labels the size of an effect by Cohen's conventional thresholds of 0.2, 0.5,
+// and 0.8 on |d|.
+func interpretCohensD(d float64) string {
+	switch d = math.Abs(d); {
+	case d < 0.2:
+		return "negligible"
+	case d < 0.5:
+		return "small"
+	case d < 0.8:
+		return "medium"
+	default:
+		return "large"
+	}
+}
+
+// outputFields are the statistics that -fields can select, by lowercase name.
+var outputFields = []struct {
+	Name  string
+	Value func(s *Stats) float64
+}{
+	{"count", func(s *Stats) float64 { return float64(s.Count) }},
+	{"sum", func(s *Stats) float64 { return s.Sum }},
+	{"min", func(s *Stats) float64 { return s.Min }},
+	{"max", func(s *Stats) float64 { return s.Max }},
+	{"mean", func(s *Stats) float64 { return s.Mean }},
+	{"median", func(s *Stats) float64 { return s.Median }},
+	{"stddev", func(s *Stats) float64 { return s.StdDev }},
+	{"variance", func(s *Stats) float64 { return s.Variance }},
+	{"rms", func(s *Stats) float64 { return s.RMS }},
+	{"cv", func(s *Stats) float64 { return s.CV }},
+	{"q1", func(s *Stats) float64 { return s.Q1 }},
+	{"q3", func(s *Stats) float64 { return s.Q3 }},
+	{"iqr", func(s *Stats) float64 { return s.IQR }},
+	{"p1", func(s *Stats) float64 { return s.P1 }},
+	{"p5", func(s *Stats) float64 { return s.P5 }},
+	{"p10", func(s *Stats) float64 { return s.P10 }},
+	{"p95", func(s *Stats) float64 { return s.P95 }},
+	{"p99", func(s *Stats) float64 { return s.P99 }},
+	{"mad", func(s *Stats) float64 { return s.MAD }},
+	{"skewness", func(s *Stats) float64 { return s.Skewness }},
+	{"kurtosis", func(s *Stats) float64 { return s.Kurtosis }},
+}
+
+// lookupField returns the accessor for a -fields name.
+func lookupField(name string) (func(s *Stats) float64, bool) {
+	for _, f := range outputFields {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// fieldNames lists the names accepted by -fields.
+func fieldNames() []string {
+	names := make([]string, len(outputFields))
+	for i, f := range outputFields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// formatFields prints one "name: value" line for each named statistic, in the given order.
+// The names must already be validated with lookupField.
+func formatFields(s *Stats, names []string) string {
+	labelWidth := 0
+	for _, name := range names {
+		labelWidth = max(labelWidth, len(name)+2)
+	}
+	var sb strings.Builder
+	for _, name := range names {
+		value, _ := lookupField(name)
+		fmt.Fprintf(&sb, "%s%s\n", padLabel(name+":", labelWidth), formatFloat(value(s)))
+	}
+	return sb.String()
+}
+
+// clippingThresholdPct is the share of values at Min or Max above which -detect-clipping
+// warns of possible saturation.
+const clippingThresholdPct = 5.0
+
+// countAtExtremes counts the values exactly equal to min and to max.
+func countAtExtremes(data []float64, min, max float64) (atMin, atMax int) {
+	for _, v := range data {
+		if v == min {
+			atMin++
+		}
+		if v == max {
+			atMax++
+		}
+	}
+	return atMin, atMax
+}
+
+// isClipped reports whether count values of n pinned at one extreme suggest saturation:
+// more than one value, making up more than clippingThresholdPct percent of the data.
+func isClipped(count, n int) bool {
+	return count > 1 && float64(count)/float64(n)*100 > clippingThresholdPct
+}
+
+// formatClipping lists how many values sit at Min and Max and warns when either looks clipped.
+func formatClipping(s *Stats, atMin, atMax int) string {
+	minLabel := fmt.Sprintf("At Min (%s):", formatFloat(s.Min))
+	maxLabel := fmt.Sprintf("At Max (%s):", formatFloat(s.Max))
+	labelWidth := max(len(minLabel), len(maxLabel)) + 2
+	pct := func(count int) string {
+		return formatFloat(float64(count)/float64(s.Count)*100) + "%"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%d (%s)\n", padLabel(minLabel, labelWidth), atMin, pct(atMin))
+	fmt.Fprintf(&sb, "%s%d (%s)\n", padLabel(maxLabel, labelWidth), atMax, pct(atMax))
+	if atMin == s.Count {
+		sb.WriteString("All values are equal; clipping cannot be assessed\n")
+		return sb.String()
+	}
+	clipped := false
+	for _, side := range []struct {
+		name  string
+		count int
+	}{{"Min", atMin}, {"Max", atMax}} {
+		if isClipped(side.count, s.Count) {
+			fmt.Fprintf(&sb, "WARNING: possible saturation at %s (%d values, %s > %s%%)\n", side.name, side.count, pct(side.count), formatFloat(clippingThresholdPct))
+			clipped = true
+		}
+	}
+	if !clipped {
+		sb.WriteString("No clipping detected\n")
+	}
+	return sb.String()
+}
+
+// baselineDriftExitCode is the exit status when -check-baseline finds the mean drifted beyond
+// the tolerance, distinct from -fail-on-outliers (2).
+const baselineDriftExitCode = 3
+
+// saveBaseline writes s to path as a timestamped JSON snapshot, in the same format as -jsonl.
+func saveBaseline(path string, s *Stats, ts time.Time) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONL(f, s, "", ts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadBaseline reads a snapshot written by saveBaseline. Custom percentiles are not restored.
+func loadBaseline(path string) (*Stats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	record := jsonlRecord{Stats: &Stats{}}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return record.Stats, nil
+}
+
+// meanDrift returns the change in the mean from baseline to current as a percent of the baseline
+// mean, and whether its magnitude is within tolerance percent. When the baseline mean is 0, any
+// change counts as infinite drift.
+func meanDrift(baseline, current *Stats, tolerance float64) (float64, bool) {
+	delta := current.Mean - baseline.Mean
+	if baseline.Mean == 0 {
+		if delta == 0 {
+			return 0, true
+		}
+		return math.Copysign(math.Inf(1), delta), false
+	}
+	drift := delta / math.Abs(baseline.Mean) * 100
+	return drift, math.Abs(drift) <= tolerance
+}
+
+// formatBaselineCheck reports the baseline and current means, the drift, and whether it is within tolerance.
+func formatBaselineCheck(baseline, current *Stats, tolerance float64) string {
+	labelWidth := 16 // len("Baseline Mean:") + 2
+	drift, ok := meanDrift(baseline, current, tolerance)
+	driftStr := "N/A (baseline mean is 0)"
+	if !math.IsInf(drift, 0) {
+		driftStr = formatSigned(drift) + "%"
+	}
+	status := fmt.Sprintf("OK (within ±%s%%)", formatFloat(tolerance))
+	if !ok {
+		status = fmt.Sprintf("ALERT (beyond ±%s%%)", formatFloat(tolerance))
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Baseline Mean:", labelWidth), formatFloat(baseline.Mean))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Current Mean:", labelWidth), formatFloat(current.Mean))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Drift:", labelWidth), driftStr)
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Status:", labelWidth), status)
+	return sb.String()
+}
+
+// formatSigned formats v like formatFloat, with a leading + for positive values.
+func formatSigned(v float64) string {
+	if v > 0 {
+		return "+" + formatFloat(v)
+	}
+	return formatFloat(v)
+}
+
+// formatTable renders rows as left-aligned columns separated by two spaces.
+func formatTable(rows [][]string) string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	var sb strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(row)-1 {
+				sb.WriteString(cell)
+			} else {
+				fmt.Fprintf(&sb, "%s%s", cell, strings.Repeat(" ", widths[i]-len([]rune(cell))+2))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// percentileRank returns the percentage of sorted data at or below v, and how many values that is.
+func percentileRank(sortedData []float64, v float64) (float64, int) {
+	atOrBelow := sort.Search(len(sortedData), func(i int) bool { return sortedData[i] > v })
+	return float64(atOrBelow) / float64(len(sortedData)) * 100, atOrBelow
+}
+
+// formatPercentileRanks lists the percentile rank of each query value against sorted data.
+func formatPercentileRanks(sortedData []float64, queries []float64) string {
+	labels := make([]string, len(queries))
+	labelWidth := 0
+	for i, q := range queries {
+		labels[i] = formatFloat(q) + ":"
+		labelWidth = max(labelWidth, len(labels[i])+2)
+	}
+	var sb strings.Builder
+	for i, q := range queries {
+		pct, count := percentileRank(sortedData, q)
+		fmt.Fprintf(&sb, "%s%s%% (%d of %d at or below)\n", padLabel(labels[i], labelWidth), formatFloat(pct), count, len(sortedData))
+	}
+	return sb.String()
+}
+
+// calculateModifiedZScores returns the modified z-score 0.6745*(x-median)/MAD of each value,
+// in input order. Unlike the plain z-score it is not inflated by the outliers it is meant to find.
+// It returns nil when MAD is 0.
+func calculateModifiedZScores(data []float64, median, mad float64) []float64 {
+	if mad == 0 {
+		return nil
+	}
+	scores := make([]float64, len(data))
+	for i, v := range data {
+		scores[i] = modZScoreFactor * (v - median) / mad
+	}
+	return scores
+}
+
+// consensusOutliers returns the IQR outliers whose modified z-score 0.6745*(x-median)/MAD also
+// exceeds threshold in magnitude, so a value is only flagged when both methods agree. It returns
+// nil when MAD is 0, since the modified z-score is then undefined.
+func consensusOutliers(iqrOutliers []float64, median, mad, threshold float64) []float64 {
+	if mad == 0 {
+		return nil
+	}
+	var agreed []float64
+	for _, v := range iqrOutliers {
+		if math.Abs(modZScoreFactor*(v-median)/mad) > threshold {
+			agreed = append(agreed, v)
+		}
+	}
+	return agreed
+}
+
+// calculateRanks returns the 1-based fractional rank of each value in data, in input order.
+// Tied values receive the average of the positions they occupy in sorted order.
+func calculateRanks(data []float64) []float64 {
+	n := len(data)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return data[order[a]] < data[order[b]] })
+
+	ranks := make([]float64, n)
+	for i := 0; i < n; {
+		j := i
+		for j+1 < n && data[order[j+1]] == data[order[i]] {
+			j++
+		}
+		// Positions i..j (0-based) are tied; their 1-based average rank is (i+1 + j+1) / 2
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[order[k]] = avg
+		}
+		i = j + 1
+	}
+	return ranks
+}
+
+// formatRanks lists each value alongside its rank, one per line in input order.
+func formatRanks(data []float64, ranks []float64) string {
+	valueWidth := 0
+	for _, v := range data {
+		valueWidth = max(valueWidth, len(formatFloat(v)))
+	}
+	var sb strings.Builder
+	for i, v := range data {
+		fmt.Fprintf(&sb, "%-*s %s\n", valueWidth, formatFloat(v), formatFloat(ranks[i]))
+	}
+	return sb.String()
+}
+
+// calculatePercentile finds the value at a given percentile (p) in sorted data using linear interpolation.
+func calculatePercentile(sortedData []float64, p float64) float64 {
+	n := len(sortedData)
+	if n == 0 {
+		return 0
+	}
+	if n == 1 {
+		return sortedData[0]
+	}
+
+	rank := p * float64(n-1)
+	lowerIndex := math.Floor(rank)
+	upperIndex := math.Ceil(rank)
+
+	if lowerIndex == upperIndex {
+		return sortedData[int(rank)]
+	}
+
+	weight := rank - lowerIndex
+	return sortedData[int(lowerIndex)]*(1-weight) + sortedData[int(upperIndex)]*weight
+}
+
+// nthValue returns the k-th order statistic of sorted data: k = 1 is the smallest value, and a
+// negative k counts from the top, so k = -1 is the largest.
+func nthValue(sortedData []float64, k int) (float64, error) {
+	n := len(sortedData)
+	if k == 0 || k > n || k < -n {
+		return 0, fmt.Errorf("nth value %d out of range; must be 1 to %d or -1 to -%d", k, n, n)
+	}
+	if k < 0 {
+		return sortedData[n+k], nil
+	}
+	return sortedData[k-1], nil
+}
+
+// percentileCI computes a distribution-free confidence interval for the p-th quantile (0 < p < 1) of
+// sorted data. The number of values below the true quantile follows Binomial(n, p), so the interval
+// between the Lower-th and Upper-th order statistics covers it with probability
+// P(Lower <= B < Upper). Each tail is kept at or below (1 - confidence) / 2.
+func percentileCI(sortedData []float64, p, confidence float64) (OrderStatCI, error) {
+	n := len(sortedData)
+	tail := (1 - confidence) / 2
+
+	// cdf[j] = P(B <= j)
+	cdf := make([]float64, n+1)
+	lnP, lnQ := math.Log(p), math.Log(1-p)
+	lgN, _ := math.Lgamma(float64(n + 1))
+	var sum float64
+	for k := 0; k <= n; k++ {
+		lgK, _ := math.Lgamma(float64(k + 1))
+		lgNK, _ := math.Lgamma(float64(n - k + 1))
+		sum += math.Exp(lgN - lgK - lgNK + float64(k)*lnP + float64(n-k)*lnQ)
+		cdf[k] = sum
+	}
+
+	lower := 0
+	for j := 1; j <= n && cdf[j-1] <= tail; j++ {
+		lower = j
+	}
+	upper := 0
+	for j := n; j >= 1 && 1-cdf[j-1] <= tail; j-- {
+		upper = j
+	}
+	if lower == 0 || upper == 0 {
+		return OrderStatCI{}, fmt.Errorf("too few values (%d) for a %s%% confidence interval at p%s", n, formatFloat(confidence*100), formatFloat(p*100))
+	}
+	return OrderStatCI{
+		Lower:      lower,
+		Upper:      upper,
+		LowerValue: sortedData[lower-1],
+		UpperValue: sortedData[upper-1],
+		Coverage:   cdf[upper-1] - cdf[lower-1],
+	}, nil
+}
+
+// regularizedGammaP returns the regularized lower incomplete gamma function P(a, x), using its
+// series expansion for x < a+1 and Lentz's continued fraction for the complement otherwise.
+func regularizedGammaP(a, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	lga, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lga)
+	if x < a+1 {
+		term := 1 / a
+		sum := term
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return sum * prefix
+	}
+
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return 1 - prefix*h
+}
+
+// chiSquareCDF returns P(X <= x) for a chi-square distribution with df degrees of freedom.
+func chiSquareCDF(x float64, df int) float64 {
+	return regularizedGammaP(float64(df)/2, x/2)
+}
+
+// chiSquareQuantile returns the x at which the chi-square CDF with df degrees of freedom equals p,
+// found by bisection.
+func chiSquareQuantile(p float64, df int) float64 {
+	lo, hi := 0.0, float64(df)
+	for chiSquareCDF(hi, df) < p {
+		hi *= 2
+	}
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		if chiSquareCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// stdDevCI returns the confidence interval for the population standard deviation at the given
+// level (0-1), sqrt((n-1)s^2/chi2_upper) to sqrt((n-1)s^2/chi2_lower). It assumes normal data.
+func stdDevCI(variance float64, n int, level float64) (float64, float64, error) {
+	if n < 2 {
+		return 0, 0, fmt.Errorf("standard deviation confidence interval requires at least 2 values, got %d", n)
+	}
+	df := n - 1
+	tail := (1 - level) / 2
+	ss := float64(df) * variance
+	return math.Sqrt(ss / chiSquareQuantile(1-tail, df)), math.Sqrt(ss / chiSquareQuantile(tail, df)), nil
+}
+
+// formatPercentileCI describes a percentile confidence interval and the order statistics bounding it.
+func formatPercentileCI(p, level float64, ci OrderStatCI) string {
+	label := fmt.Sprintf("p%s (%s%% CI):", formatFloat(p), formatFloat(level))
+	labelWidth := max(len(label), len("Order Statistics:")) + 2
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s[%s, %s]\n", padLabel(label, labelWidth), formatFloat(ci.LowerValue), formatFloat(ci.UpperValue))
+	fmt.Fprintf(&sb, "%s%d and %d\n", padLabel("Order Statistics:", labelWidth), ci.Lower, ci.Upper)
+	fmt.Fprintf(&sb, "%s%s%%\n", padLabel("Exact Coverage:", labelWidth), formatFloat(ci.Coverage*100))
+	return sb.String()
+}
+
+// seedFromData derives a deterministic RNG seed from an FNV-1a hash of the values in input order,
+// so identical data always produces identical bootstrap results.
+func seedFromData(data []float64) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, v := range data {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// resolveSeed turns the -seed flag value into a seed: "auto" hashes the data,
+// an empty value uses the current time, and anything else must be a non-negative integer.
+func resolveSeed(flagValue string, data []float64) uint64 {
+	switch flagValue {
+	case "auto":
+		return seedFromData(data)
+	case "":
+		return uint64(time.Now().UnixNano())
+	}
+	seed, _ := strconv.ParseUint(flagValue, 10, 64) // validated in main
+	return seed
+}
+
+// bootstrapMeanCI estimates a confidence interval for the mean by resampling data with replacement
+// and taking the percentile interval of the resampled means.
+func bootstrapMeanCI(data []float64, resamples int, level float64, rng *rand.Rand) (float64, float64) {
+	n := len(data)
+	means := make([]float64, resamples)
+	for i := range means {
+		sum := 0.0
+		for j := 0; j < n; j++ {
+			sum += data[rng.IntN(n)]
+		}
+		means[i] = sum / float64(n)
+	}
+	sort.Float64s(means)
+	alpha := (1 - level) / 2
+	return calculatePercentile(means, alpha), calculatePercentile(means, 1-alpha)
+}
+
+// formatBootstrapCI formats a bootstrap confidence interval for the mean along with the seed needed to reproduce it.
+func formatBootstrapCI(level, lower, upper float64, resamples int, seed uint64) string {
+	label := fmt.Sprintf("Mean (%s%% CI):", formatFloat(level))
+	labelWidth := max(len(label), len("Resamples:")) + 2
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s[%s, %s]\n", padLabel(label, labelWidth), formatFloat(lower), formatFloat(upper))
+	fmt.Fprintf(&sb, "%s%d\n", padLabel("Resamples:", labelWidth), resamples)
+	fmt.Fprintf(&sb, "%s%d\n", padLabel("Seed:", labelWidth), seed)
+	return sb.String()
+}
+
+// computeWeightedPercentile returns the weighted p-th quantile (0 <= p <= 1): the smallest value at
+// which the cumulative weight, in ascending value order, reaches p times the total weight. When the
+// cumulative weight lands exactly on the target, the result is the average of that value and the
+// next one with positive weight, so equal weights give the ordinary median. Zero weights are ignored.
+func computeWeightedPercentile(values, weights []float64, p float64) (float64, error) {
+	if len(values) != len(weights) {
+		return 0, fmt.Errorf("got %d values but %d weights", len(values), len(weights))
+	}
+	if p < 0 || p > 1 {
+		return 0, fmt.Errorf("percentile must be between 0 and 1, got %v", p)
+	}
+	idx := make([]int, 0, len(values))
+	total := 0.0
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return 0, fmt.Errorf("weight %v for value %s is not a finite non-negative number", w, formatFloat(values[i]))
+		}
+		if w > 0 {
+			idx = append(idx, i)
+			total += w
+		}
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("total weight is zero")
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return values[idx[a]] < values[idx[b]] })
+
+	target := p * total
+	tieTolerance := 1e-12 * total
+	cum := 0.0
+	for k, i := range idx {
+		cum += weights[i]
+		if math.Abs(cum-target) <= tieTolerance && k+1 < len(idx) {
+			return (values[i] + values[idx[k+1]]) / 2, nil
+		}
+		if cum >= target {
+			return values[i], nil
+		}
+	}
+	return values[idx[len(idx)-1]], nil
+}
+
+// weightedMedian returns the weighted median of values; see computeWeightedPercentile.
+func weightedMedian(values, weights []float64) (float64, error) {
+	return computeWeightedPercentile(values, weights, 0.5)
+}
+
+// calculatePercentileMidpoint calculates the p-th percentile using midpoint interpolation:
+// when the rank falls between two values, it returns their average regardless of the fractional position.
+func calculatePercentileMidpoint(sortedData []float64, p float64) float64 {
+	n := len(sortedData)
+	if n == 0 {
+		return 0
+	}
+	if n == 1 {
+		return sortedData[0]
+	}
+
+	rank := p * float64(n-1)
+	lowerIndex := math.Floor(rank)
+	upperIndex := math.Ceil(rank)
+
+	if lowerIndex == upperIndex {
+		return sortedData[int(rank)]
+	}
+
+	return (sortedData[int(lowerIndex)] + sortedData[int(upperIndex)]) / 2
+}
+
+// calculatePositiveMeans computes the geometric and harmonic means. Both are only defined when
+// every value is positive; ok is false otherwise.
+func calculatePositiveMeans(data []float64) (geometric, harmonic float64, ok bool) {
+	var sumLog, sumRecip float64
+	for _, v := range data {
+		if v <= 0 {
+			return 0, 0, false
+		}
+		sumLog += math.Log(v)
+		sumRecip += 1 / v
+	}
+	n := float64(len(data))
+	return math.Exp(sumLog / n), n / sumRecip, true
+}
+
+// meansRelationship describes how the arithmetic, geometric, and harmonic means compare.
+// For positive data AM >= GM >= HM always holds, with equality only when all values are equal.
+func meansRelationship(am, gm, hm float64) string {
+	tolerance := 1e-9 * math.Max(1, math.Abs(am))
+	switch {
+	case am-gm < tolerance && gm-hm < tolerance:
+		return "AM = GM = HM (all values are equal)"
+	case am >= gm-tolerance && gm >= hm-tolerance:
+		return "AM > GM > HM (holds; a wider gap means more relative spread)"
+	default:
+		return "AM >= GM >= HM does not hold (numerical precision issue)"
+	}
+}
+
+// formatMeansComparison lists the three means and their relationship.
+func formatMeansComparison(s *Stats) string {
+	labelWidth := 18 // len("Arithmetic Mean:") + 2
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Arithmetic Mean:", labelWidth), formatFloat(s.Mean))
+	if !s.PositiveMeans {
+		fmt.Fprintf(&sb, "%s%s\n", padLabel("Geometric Mean:", labelWidth), "N/A - requires all positive values")
+		fmt.Fprintf(&sb, "%s%s\n", padLabel("Harmonic Mean:", labelWidth), "N/A - requires all positive values")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Geometric Mean:", labelWidth), formatFloat(s.GeometricMean))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Harmonic Mean:", labelWidth), formatFloat(s.HarmonicMean))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Relationship:", labelWidth), meansRelationship(s.Mean, s.GeometricMean, s.HarmonicMean))
+	return sb.String()
+}
+
+// GeometricMeanCI is a geometric mean with a confidence interval computed on the log scale
+// and exponentiated back, so the interval is multiplicative: Mean ×/÷ Factor.
+type GeometricMeanCI struct {
+	Mean         float64 // exp(mean of ln x)
+	Lower, Upper float64 // exp(mean of ln x ± z * SE)
+	Factor       float64 // Upper / Mean, which also equals Mean / Lower
+	GeometricSD  float64 // exp(sample standard deviation of ln x)
+}
+
+// geometricMeanCI log-transforms data, builds a normal-approximation confidence interval for the
+// log-scale mean at the given level (0-1), and back-transforms it. It requires at least two positive values.
+func geometricMeanCI(data []float64, level float64) (GeometricMeanCI, error) {
+	if len(data) < 2 {
+		return GeometricMeanCI{}, fmt.Errorf("geometric mean confidence interval requires at least 2 values, got %d", len(data))
+	}
+	logs, err := applyLogTransform(data)
+	if err != nil {
+		return GeometricMeanCI{}, err
+	}
+	n := float64(len(logs))
+	var sum float64
+	for _, v := range logs {
+		sum += v
+	}
+	mean := sum / n
+	var sumSq float64
+	for _, v := range logs {
+		sumSq += (v - mean) * (v - mean)
+	}
+	sd := math.Sqrt(sumSq / (n - 1))
+	z := standardNormalQuantile(1 - (1-level)/2)
+	margin := z * sd / math.Sqrt(n)
+	return GeometricMeanCI{
+		Mean:        math.Exp(mean),
+		Lower:       math.Exp(mean - margin),
+		Upper:       math.Exp(mean + margin),
+		Factor:      math.Exp(margin),
+		GeometricSD: math.Exp(sd),
+	}, nil
+}
+
+// formatGeometricMeanCI formats a geometric mean report at the given confidence level (percent).
+func formatGeometricMeanCI(ci GeometricMeanCI, level float64) string {
+	labelWidth := 17 // len("Geometric Mean:") + 2
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Geometric Mean:", labelWidth), formatFloat(ci.Mean))
+	fmt.Fprintf(&sb, "%s[%s, %s] (×/÷ %s)\n", padLabel(fmt.Sprintf("%s%% CI:", formatFloat(level)), labelWidth), formatFloat(ci.Lower), formatFloat(ci.Upper), formatFloat(ci.Factor))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Geometric SD:", labelWidth), formatFloat(ci.GeometricSD))
+	return sb.String()
+}
+
+// formatSignBreakdown lists the count, percentage of the total count, and sum of the positive,
+// negative, and zero values.
+func formatSignBreakdown(s *Stats) string {
+	labelWidth := 11 // len("Negative:") + 2
+	var sb strings.Builder
+	line := func(label string, count int, sum float64) {
+		pct := float64(count) / float64(s.Count) * 100
+		fmt.Fprintf(&sb, "%s%d (%s%%), sum %s\n", padLabel(label, labelWidth), count, formatFloat(pct), formatFloat(sum))
+	}
+	line("Positive:", s.PositiveCount, s.PositiveSum)
+	line("Negative:", s.NegativeCount, s.NegativeSum)
+	line("Zero:", s.ZeroCount, 0)
+	return sb.String()
+}
+
+// valueFrequencies counts how often each value occurs in sorted data, in ascending order. With eps > 0,
+// values within eps of the smallest value of a run are counted together under that smallest value,
+// so 50 and 50.0000001 group at eps 1e-6. eps 0 groups only exactly equal values.
+func valueFrequencies(sortedData []float64, eps float64) []ValueCount {
+	var freqs []ValueCount
+	for _, v := range sortedData {
+		if last := len(freqs) - 1; last >= 0 && v-freqs[last].Value <= eps {
+			freqs[last].Count++
 			continue
 		}
-		numbers = append(numbers, num)
+		freqs = append(freqs, ValueCount{Value: v, Count: 1})
 	}
-	return numbers, scanner.Err()
+	return freqs
 }
 
-// applyLogTransform applies natural log to all values, returning an error if any value is <= 0.
-func applyLogTransform(numbers []float64) ([]float64, error) {
-	result := make([]float64, len(numbers))
-	for i, v := range numbers {
-		if v <= 0 {
-			return nil, fmt.Errorf("log transform requires all positive values, but got %v", v)
+// modesAndDuplicates returns the most frequent values in ascending order, or an empty slice when no
+// value repeats, and the values occurring more than once, most frequent first.
+func modesAndDuplicates(freqs []ValueCount) (modes []float64, duplicates []ValueCount) {
+	maxFreq := 0
+	for _, f := range freqs {
+		maxFreq = max(maxFreq, f.Count)
+		if f.Count > 1 {
+			duplicates = append(duplicates, f)
 		}
-		result[i] = math.Log(v)
 	}
-	return result, nil
-}
+	sort.SliceStable(duplicates, func(i, j int) bool { return duplicates[i].Count > duplicates[j].Count })
 
-// computeStats calculates all the desired statistics for a slice of numbers.
-func computeStats(data []float64, customPercentiles []float64, iqrMultiplier float64, numBins int, zScoreThreshold float64, trimPct float64, emaSpan int) (*Stats, error) {
-	count := len(data)
-	if count == 0 {
-		return nil, fmt.Errorf("input contains no valid numbers")
+	// If the max frequency is 1, no number repeated, so there is no mode.
+	modes = []float64{}
+	if maxFreq > 1 {
+		for _, f := range freqs {
+			if f.Count == maxFreq {
+				modes = append(modes, f.Value)
+			}
+		}
 	}
+	return modes, duplicates
+}
 
-	// Create a sorted copy for calculations that require it (median, quartiles).
-	sortedData := make([]float64, count)
-	copy(sortedData, data)
-	sort.Float64s(sortedData)
+// formatTotals renders the aggregate totals for accounting-style data: the count, the number of distinct
+// values, the sum, and the sum of absolute values.
+func formatTotals(s *Stats) string {
+	labelWidth := 11 // len("Distinct:") + 2
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%d\n", padLabel("Count:", labelWidth), s.Count)
+	fmt.Fprintf(&sb, "%s%d\n", padLabel("Distinct:", labelWidth), s.DistinctCount)
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Sum:", labelWidth), formatFloat(s.Sum))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Sum |x|:", labelWidth), formatFloat(s.SumAbs))
+	return sb.String()
+}
 
-	// --- Basic Stats ---
-	stats := &Stats{
-		Count: count,
-		Min:   sortedData[0],
-		Max:   sortedData[count-1],
+// calculateTargetError computes the mean absolute percent error, mean(|x-T|/|T|)*100, and the
+// mean bias, mean(x-T), of data relative to a known target T. T must not be zero.
+func calculateTargetError(data []float64, target float64) (mape, bias float64) {
+	if len(data) == 0 {
+		return 0, 0
 	}
-
-	// --- Mean ---
-	var sum float64
+	var sumAbsPct, sumDiff float64
 	for _, v := range data {
-		sum += v
+		sumAbsPct += math.Abs(v-target) / math.Abs(target)
+		sumDiff += v - target
 	}
-	stats.Sum = sum
-	stats.Mean = sum / float64(count)
+	n := float64(len(data))
+	return sumAbsPct / n * 100, sumDiff / n
+}
 
-	// --- Trimmed Mean ---
-	if trimPct > 0 {
-		trimCount := int(math.Floor(float64(count) * trimPct / 100.0))
-		remaining := count - 2*trimCount
-		if remaining < 1 {
-			return nil, fmt.Errorf("dataset too small (%d values) to trim %.4g%% from each end", count, trimPct)
-		}
-		trimmed := sortedData[trimCount : count-trimCount]
-		var trimSum float64
-		for _, v := range trimmed {
-			trimSum += v
-		}
-		stats.TrimmedMean = trimSum / float64(remaining)
-		stats.TrimmedMeanPct = trimPct
+// bowleySkewness computes the quartile skewness (Q3 + Q1 - 2*median) / IQR, which lies in [-1, 1] and
+// is unaffected by the tails. ok is false when the IQR is zero.
+func bowleySkewness(s *Stats) (skew float64, ok bool) {
+	if s.IQR == 0 {
+		return 0, false
 	}
+	return (s.Q3 + s.Q1 - 2*s.Median) / s.IQR, true
+}
 
-	// --- Variance and Standard Deviation ---
-	if count > 1 {
-		var sumOfSquares float64
-		for _, v := range data {
-			sumOfSquares += math.Pow(v-stats.Mean, 2)
-		}
-		// Using sample variance (N-1), which is more common.
-		stats.Variance = sumOfSquares / float64(count-1)
-		stats.StdDev = math.Sqrt(stats.Variance)
+// formatRobustSummary lists only statistics that are resistant to outliers: the median, MAD, IQR,
+// Bowley skewness, and trimmed mean (when computed).
+func formatRobustSummary(s *Stats) string {
+	labelWidth := 21 // len("Trimmed Mean (10%):") + 2
+	var sb strings.Builder
+	sb.WriteString("--- Robust Summary ---\n")
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Median (p50):", labelWidth), formatFloat(s.Median))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("MAD:", labelWidth), formatFloat(s.MAD))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("MAD (scaled):", labelWidth), formatFloat(s.ScaledMAD))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("IQR:", labelWidth), formatFloat(s.IQR))
+	if skew, ok := bowleySkewness(s); ok {
+		fmt.Fprintf(&sb, "%s%s\n", padLabel("Bowley Skewness:", labelWidth), formatFloat(skew))
+	} else {
+		fmt.Fprintf(&sb, "%s%s\n", padLabel("Bowley Skewness:", labelWidth), "N/A (IQR is 0)")
+	}
+	if label, ok := trimmedMeanLabel(s); ok {
+		fmt.Fprintf(&sb, "%s%s\n", padLabel(label, labelWidth), formatFloat(s.TrimmedMean))
 	}
+	return sb.String()
+}
 
-	// --- Median, Q1, Q3, P95, P99 (Percentiles) ---
-	stats.Median = calculatePercentile(sortedData, 0.50)
-	stats.Q1 = calculatePercentile(sortedData, 0.25)
-	stats.Q3 = calculatePercentile(sortedData, 0.75)
-	stats.P95 = calculatePercentile(sortedData, 0.95)
-	stats.P99 = calculatePercentile(sortedData, 0.99)
+// formatTargetError lists the MAPE and mean bias relative to the target.
+func formatTargetError(s *Stats) string {
+	labelWidth := 12 // len("Mean Bias:") + 2
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s%%\n", padLabel("MAPE:", labelWidth), formatFloat(s.MAPE))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Mean Bias:", labelWidth), formatFloat(s.Bias))
+	return sb.String()
+}
 
-	// --- Custom Percentiles ---
-	if len(customPercentiles) > 0 {
-		stats.CustomPercentiles = make(map[float64]float64)
-		for _, p := range customPercentiles {
-			stats.CustomPercentiles[p] = calculatePercentile(sortedData, p/100.0)
-		}
+// calculateMAD computes the median of the absolute deviations from the median.
+func calculateMAD(data []float64, median float64) float64 {
+	deviations := make([]float64, len(data))
+	for i, v := range data {
+		deviations[i] = math.Abs(v - median)
 	}
+	sort.Float64s(deviations)
+	return calculatePercentile(deviations, 0.50)
+}
 
-	// --- IQR ---
-	stats.IQR = stats.Q3 - stats.Q1
+// calculateSkewness computes the adjusted Fisher-Pearson standardized moment coefficient.
+func calculateSkewness(data []float64, mean, stdDev float64) float64 {
+	n := float64(len(data))
+	if n < 3 || stdDev == 0 {
+		return 0 // Skewness is not defined for less than 3 points or zero std dev
+	}
 
-	// --- Mode (single-pass efficient algorithm) ---
-	freqs := make(map[float64]int)
+	var sumOfCubedDeviations float64
 	for _, v := range data {
-		freqs[v]++
+		sumOfCubedDeviations += math.Pow(v-mean, 3)
 	}
 
-	var modes []float64
-	maxFreq := 0 // Start at 0 to correctly find the max frequency
-	for val, freq := range freqs {
-		if freq > maxFreq {
-			maxFreq = freq
-			modes = []float64{val} // New max, reset the slice
-		} else if freq == maxFreq {
-			modes = append(modes, val) // Found another mode
-		}
+	// Formula for sample skewness
+	return (n / ((n - 1) * (n - 2))) * (sumOfCubedDeviations / math.Pow(stdDev, 3))
+}
+
+// calculateKurtosis computes the sample excess kurtosis.
+func calculateKurtosis(data []float64, mean, stdDev float64) float64 {
+	n := float64(len(data))
+	if n < 4 || stdDev == 0 {
+		return 0
 	}
+	var sumOfFourthDeviations float64
+	for _, v := range data {
+		sumOfFourthDeviations += math.Pow((v-mean)/stdDev, 4)
+	}
+	// Excess kurtosis using the sample formula
+	return (n*(n+1))/((n-1)*(n-2)*(n-3))*sumOfFourthDeviations - 3*(n-1)*(n-1)/((n-2)*(n-3))
+}
 
-	// If the max frequency is 1, it means no number repeated, so there is no mode.
-	if maxFreq <= 1 {
-		stats.Mode = []float64{} // Return an empty slice
-	} else {
-		stats.Mode = modes
-		sort.Float64s(stats.Mode) // For consistent output
+// ShapeTest is a z-test of a sample shape statistic (skewness or excess kurtosis) against 0.
+type ShapeTest struct {
+	Value       float64 // the statistic being tested
+	SE          float64 // standard error of the statistic under normality
+	Z           float64 // Value / SE
+	Significant bool    // |Z| exceeds the two-sided 5% critical value
+}
+
+// skewnessStdError returns the standard error of the sample skewness for n values,
+// sqrt(6n(n-1) / ((n-2)(n+1)(n+3))).
+func skewnessStdError(n int) float64 {
+	fn := float64(n)
+	return math.Sqrt(6 * fn * (fn - 1) / ((fn - 2) * (fn + 1) * (fn + 3)))
+}
+
+// newShapeTest builds the z-test for value given its standard error.
+func newShapeTest(value, se float64) ShapeTest {
+	z := value / se
+	return ShapeTest{Value: value, SE: se, Z: z, Significant: math.Abs(z) > standardNormalQuantile(0.975)}
+}
+
+// skewnessTest tests whether the sample skewness of n values differs significantly from 0.
+func skewnessTest(skewness float64, n int) (ShapeTest, error) {
+	if n < 3 {
+		return ShapeTest{}, fmt.Errorf("skewness test requires at least 3 values, got %d", n)
 	}
+	return newShapeTest(skewness, skewnessStdError(n)), nil
+}
 
-	// --- Outliers (using the k * IQR rule) ---
-	lowerBound := stats.Q1 - iqrMultiplier*stats.IQR
-	upperBound := stats.Q3 + iqrMultiplier*stats.IQR
+// kurtosisTest tests whether the sample excess kurtosis of n values differs significantly from 0.
+// Its standard error is 2 * SE(skewness) * sqrt((n^2-1) / ((n-3)(n+5))).
+func kurtosisTest(kurtosis float64, n int) (ShapeTest, error) {
+	if n < 4 {
+		return ShapeTest{}, fmt.Errorf("kurtosis test requires at least 4 values, got %d", n)
+	}
+	fn := float64(n)
+	se := 2 * skewnessStdError(n) * math.Sqrt((fn*fn-1)/((fn-3)*(fn+5)))
+	return newShapeTest(kurtosis, se), nil
+}
+
+// boxPlotColumn maps v onto one of width columns spanning [lo, hi].
+func boxPlotColumn(v, lo, hi float64, width int) int {
+	if hi == lo {
+		return width / 2
+	}
+	return int(math.Round((v - lo) / (hi - lo) * float64(width-1)))
+}
+
+// formatBoxPlot renders a horizontal box plot scaled from Min to Max across width columns: whisker caps
+// (|) at the most extreme non-outlier values, a box ([ and ]) from Q1 to Q3 with the median (|) inside,
+// and o or * markers for mild and extreme outliers. inFences holds the sorted non-outliers; when it is
+// empty the whiskers collapse onto the box. The box edges are drawn over a median in the same column.
+func formatBoxPlot(s *Stats, inFences []float64, width int) string {
+	col := func(v float64) int { return boxPlotColumn(v, s.Min, s.Max, width) }
+	line := []rune(strings.Repeat(" ", width))
 
+	q1, q3 := col(s.Q1), col(s.Q3)
+	lowWhisker, highWhisker := q1, q3
+	if len(inFences) > 0 {
+		lowWhisker, highWhisker = col(inFences[0]), col(inFences[len(inFences)-1])
+	}
+	for i := lowWhisker; i <= highWhisker; i++ {
+		line[i] = '-'
+	}
+	line[lowWhisker], line[highWhisker] = '|', '|'
+	for i := q1; i <= q3; i++ {
+		line[i] = '='
+	}
+	line[col(s.Median)] = '|'
+	line[q1], line[q3] = '[', ']'
+	for _, v := range s.MildOutliers {
+		line[col(v)] = 'o'
+	}
+	for _, v := range s.ExtremeOutliers {
+		line[col(v)] = '*'
+	}
+
+	minLabel, maxLabel := formatFloat(s.Min), formatFloat(s.Max)
+	gap := max(1, width-len(minLabel)-len(maxLabel))
+	return strings.TrimRight(string(line), " ") + "\n" + minLabel + strings.Repeat(" ", gap) + maxLabel + "\n"
+}
+
+// heapingZ is the one-sided z threshold (5% level) above which the share of values ending in 0 or 5
+// counts as heaping.
+const heapingZ = 1.645
+
+// DigitBias is the last-digit distribution of the integer values in a dataset. Without digit
+// preference each digit ends about 10% of the values, and 0 or 5 about 20%.
+type DigitBias struct {
+	Counts     [10]int // values ending in each digit
+	Total      int     // integer values counted
+	Skipped    int     // non-integer values ignored
+	ChiSquare  float64 // chi-square statistic against a uniform distribution (9 degrees of freedom)
+	P          float64 // p-value of ChiSquare
+	RoundShare float64 // fraction of values ending in 0 or 5
+	Z          float64 // (RoundShare - 0.2) / sqrt(0.2 * 0.8 / Total)
+	Heaping    bool    // Z exceeds heapingZ
+}
+
+// digitBias buckets the integer values of data by their last digit and tests for digit preference.
+// Non-integer values are skipped. It returns an error when there are no integer values.
+func digitBias(data []float64) (DigitBias, error) {
+	var b DigitBias
 	for _, v := range data {
-		if v < lowerBound || v > upperBound {
-			stats.Outliers = append(stats.Outliers, v)
+		if v != math.Trunc(v) || math.IsInf(v, 0) {
+			b.Skipped++
+			continue
 		}
+		b.Counts[int(math.Mod(math.Abs(v), 10))]++
+		b.Total++
 	}
-	sort.Float64s(stats.Outliers) // For consistent output
+	if b.Total == 0 {
+		return b, fmt.Errorf("digit bias needs integer values, got none")
+	}
+	n := float64(b.Total)
+	expected := n / 10
+	for _, c := range b.Counts {
+		d := float64(c) - expected
+		b.ChiSquare += d * d / expected
+	}
+	b.P = 1 - chiSquareCDF(b.ChiSquare, 9)
+	b.RoundShare = float64(b.Counts[0]+b.Counts[5]) / n
+	b.Z = (b.RoundShare - 0.2) / math.Sqrt(0.2*0.8/n)
+	b.Heaping = b.Z > heapingZ
+	return b, nil
+}
 
-	// --- Z-Score Outliers ---
-	if zScoreThreshold > 0 && stats.StdDev > 0 {
-		stats.ZScoreThreshold = zScoreThreshold
-		for _, v := range data {
-			z := math.Abs((v - stats.Mean) / stats.StdDev)
-			if z > zScoreThreshold {
-				stats.ZScoreOutliers = append(stats.ZScoreOutliers, v)
-			}
+// formatDigitBias renders the count and share of each last digit, the uniformity test, and whether
+// values heap on 0 and 5.
+func formatDigitBias(b DigitBias) string {
+	labelWidth := 13 // len("Chi-square:") + 2
+	var sb strings.Builder
+	values := fmt.Sprintf("%d integers", b.Total)
+	if b.Skipped > 0 {
+		values += fmt.Sprintf(" (%d non-integer skipped)", b.Skipped)
+	}
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Values:", labelWidth), values)
+	for d, c := range b.Counts {
+		fmt.Fprintf(&sb, "%s%d (%s%%)\n", padLabel(fmt.Sprintf("Digit %d:", d), labelWidth), c, formatFloat(float64(c)/float64(b.Total)*100))
+	}
+	fmt.Fprintf(&sb, "%s%s (p %s, df 9)\n", padLabel("Chi-square:", labelWidth), formatFloat(b.ChiSquare), formatFloat(b.P))
+	fmt.Fprintf(&sb, "%s%s%% (expected 20%%, z %s)\n", padLabel("0s and 5s:", labelWidth), formatFloat(b.RoundShare*100), formatFloat(b.Z))
+	heaping := "no"
+	if b.Heaping {
+		heaping = "yes - excess of values ending in 0 or 5"
+	}
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Heaping:", labelWidth), heaping)
+	return sb.String()
+}
+
+// minStemLines is the fewest stems formatStemLeaf aims for when choosing the leaf unit.
+const minStemLines = 5
+
+// stemLeafOrdinal splits v, in units of u, into a stem ordinal and a leaf digit. Non-negative stems
+// have ordinals 0, 1, 2, ...; negative stems -0, -1, -2, ... have ordinals -1, -2, -3, ..., so that
+// ordinals increase with value.
+func stemLeafOrdinal(v, u float64) (ord, leaf int) {
+	m := int(math.Round(v / u))
+	if v < 0 {
+		m = -m
+		return -(m / 10) - 1, m % 10
+	}
+	return m / 10, m % 10
+}
+
+// stemLeafUnit picks the leaf unit for a stem-and-leaf plot: the largest power of ten, down to 0.1,
+// that spreads the sorted data over at least minStemLines stems.
+func stemLeafUnit(sortedData []float64) float64 {
+	lo, hi := sortedData[0], sortedData[len(sortedData)-1]
+	mag := math.Max(math.Abs(lo), math.Abs(hi))
+	k := -1
+	if mag > 0 {
+		k = int(math.Floor(math.Log10(mag)))
+	}
+	for ; k > -1; k-- {
+		u := math.Pow(10, float64(k))
+		loOrd, _ := stemLeafOrdinal(lo, u)
+		hiOrd, _ := stemLeafOrdinal(hi, u)
+		if hiOrd-loOrd+1 >= minStemLines {
+			return u
 		}
-		sort.Float64s(stats.ZScoreOutliers)
 	}
+	return 0.1
+}
 
-	// --- Skewness (formal calculation) ---
-	stats.Skewness = calculateSkewness(data, stats.Mean, stats.StdDev)
+// formatStemLeaf renders a stem-and-leaf plot of sorted data: each line is a stem followed by one
+// digit per value, where a value is (stem*10 + leaf) leaf units. Values are rounded to the leaf unit,
+// and empty stems inside the range are kept so gaps stay visible.
+func formatStemLeaf(sortedData []float64) string {
+	if len(sortedData) == 0 {
+		return ""
+	}
+	u := stemLeafUnit(sortedData)
+	leaves := make(map[int][]byte)
+	for _, v := range sortedData {
+		ord, leaf := stemLeafOrdinal(v, u)
+		leaves[ord] = append(leaves[ord], byte('0'+leaf))
+	}
+	loOrd, _ := stemLeafOrdinal(sortedData[0], u)
+	hiOrd, _ := stemLeafOrdinal(sortedData[len(sortedData)-1], u)
 
-	// --- Kurtosis (excess kurtosis) ---
-	stats.Kurtosis = calculateKurtosis(data, stats.Mean, stats.StdDev)
+	stemLabel := func(ord int) string {
+		if ord < 0 {
+			return "-" + strconv.Itoa(-ord-1)
+		}
+		return strconv.Itoa(ord)
+	}
+	width := max(len(stemLabel(loOrd)), len(stemLabel(hiOrd)))
 
-	// --- Check for negative data ---
+	var sb strings.Builder
+	firstOrd, firstLeaf := stemLeafOrdinal(sortedData[0], u)
+	fmt.Fprintf(&sb, "Leaf unit: %s (%s | %c = %s)\n", formatFloat(u), stemLabel(firstOrd), '0'+firstLeaf, formatFloat(math.Round(sortedData[0]/u)*u))
+	for ord := loOrd; ord <= hiOrd; ord++ {
+		line := fmt.Sprintf("%*s | %s", width, stemLabel(ord), leaves[ord])
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return sb.String()
+}
+
+// percentWithinSD returns the percent of values within 1, 2, and 3 standard deviations of the mean,
+// counted in a single pass.
+func percentWithinSD(data []float64, mean, stdDev float64) (one, two, three float64) {
+	var n1, n2, n3 int
 	for _, v := range data {
-		if v < 0 {
-			stats.HasNegativeData = true
-			break
+		d := math.Abs(v - mean)
+		if d <= stdDev {
+			n1++
+		}
+		if d <= 2*stdDev {
+			n2++
+		}
+		if d <= 3*stdDev {
+			n3++
 		}
 	}
+	n := float64(len(data))
+	return float64(n1) / n * 100, float64(n2) / n * 100, float64(n3) / n * 100
+}
 
-	// --- Coefficient of Variation ---
-	if math.Abs(stats.Mean) < 1e-10 {
-		stats.CVValid = false
-	} else {
-		stats.CVValid = true
-		stats.CV = (stats.StdDev / math.Abs(stats.Mean)) * 100
+// formatEmpiricalRule lists the percent of values within 1, 2, and 3 standard deviations next to
+// the 68-95-99.7 rule's expectation for normal data.
+func formatEmpiricalRule(s *Stats) string {
+	labelWidth := 14 // len("Within 1 SD:") + 2
+	var sb strings.Builder
+	for _, row := range []struct {
+		label    string
+		actual   float64
+		expected float64
+	}{
+		{"Within 1 SD:", s.WithinOneSD, 68.27},
+		{"Within 2 SD:", s.WithinTwoSD, 95.45},
+		{"Within 3 SD:", s.WithinThreeSD, 99.73},
+	} {
+		fmt.Fprintf(&sb, "%s%s%% (normal: %s%%)\n", padLabel(row.label, labelWidth), formatFloat(row.actual), formatFloat(row.expected))
 	}
+	return sb.String()
+}
 
-	// --- EMA ---
-	if emaSpan >= 2 {
-		stats.EMA = calculateEMA(data, emaSpan)
-		stats.EMASpan = emaSpan
+// qqNormalThreshold is the QQ correlation at or above which -normality treats the data as
+// consistent with a normal distribution.
+const qqNormalThreshold = 0.98
+
+// normalityCheck is the outcome of one normality test in the -normality report.
+type normalityCheck struct {
+	Name   string
+	Detail string // test statistic and how it compares
+	Pass   bool   // consistent with a normal distribution
+}
+
+// jarqueBera returns the Jarque-Bera statistic n/6 * (S^2 + K^2/4) for skewness S and excess
+// kurtosis K, and its p-value from the chi-square distribution with 2 degrees of freedom.
+func jarqueBera(skewness, kurtosis float64, n int) (jb, p float64) {
+	jb = float64(n) / 6 * (skewness*skewness + kurtosis*kurtosis/4)
+	return jb, 1 - chiSquareCDF(jb, 2)
+}
+
+// normalityChecks runs the Jarque-Bera, QQ correlation, skewness, and kurtosis tests at the 5%
+// level (QQ against qqNormalThreshold). It requires at least 4 values.
+func normalityChecks(data []float64, s *Stats) ([]normalityCheck, error) {
+	if s.Count < 4 {
+		return nil, fmt.Errorf("normality checks require at least 4 values, got %d", s.Count)
+	}
+	verdict := func(pass bool) string {
+		if pass {
+			return "pass"
+		}
+		return "fail"
 	}
 
-	// --- Histogram ---
-	stats.Histogram = generateHistogram(sortedData, numBins)
+	jb, p := jarqueBera(s.Skewness, s.Kurtosis, s.Count)
+	jbPass := p > 0.05
+	checks := []normalityCheck{{"Jarque-Bera", fmt.Sprintf("JB %s, p %s (%s)", formatFloat(jb), formatFloat(p), verdict(jbPass)), jbPass}}
 
-	// --- Trendline ---
-	stats.Trendline = generateTrendline(data, numBins)
+	if r, ok := calculateQQCorrelation(data); ok {
+		pass := r >= qqNormalThreshold
+		cmp := ">="
+		if !pass {
+			cmp = "<"
+		}
+		checks = append(checks, normalityCheck{"QQ Correlation", fmt.Sprintf("r %s %s %s (%s)", formatFloat(r), cmp, formatFloat(qqNormalThreshold), verdict(pass)), pass})
+	} else {
+		checks = append(checks, normalityCheck{"QQ Correlation", "N/A - constant data (fail)", false})
+	}
 
-	return stats, nil
+	skew, _ := skewnessTest(s.Skewness, s.Count)
+	kurt, _ := kurtosisTest(s.Kurtosis, s.Count)
+	checks = append(checks,
+		normalityCheck{"Skewness Test", fmt.Sprintf("z %s (%s)", formatFloat(skew.Z), verdict(!skew.Significant)), !skew.Significant},
+		normalityCheck{"Kurtosis Test", fmt.Sprintf("z %s (%s)", formatFloat(kurt.Z), verdict(!kurt.Significant)), !kurt.Significant},
+	)
+	return checks, nil
 }
 
-// generateHistogram creates a Unicode histogram from sorted data.
-func generateHistogram(sortedData []float64, numBins int) string {
-	n := len(sortedData)
-	if n < 2 {
-		return ""
+// normalityConclusion summarizes checks by majority vote; a tie is inconclusive.
+func normalityConclusion(checks []normalityCheck) string {
+	passed := 0
+	for _, c := range checks {
+		if c.Pass {
+			passed++
+		}
 	}
-	minVal := sortedData[0]
-	maxVal := sortedData[n-1]
-	if minVal == maxVal {
-		return ""
+	var conclusion string
+	switch {
+	case passed*2 > len(checks):
+		conclusion = "likely normal"
+	case passed*2 < len(checks):
+		conclusion = "likely not normal"
+	default:
+		conclusion = "inconclusive"
 	}
+	return fmt.Sprintf("%s (%d of %d checks pass)", conclusion, passed, len(checks))
+}
 
-	binWidth := (maxVal - minVal) / float64(numBins)
-	bins := make([]int, numBins)
+// formatNormality lists each normality check and the majority conclusion.
+func formatNormality(checks []normalityCheck) string {
+	labelWidth := 17 // len("QQ Correlation:") + 2
+	var sb strings.Builder
+	for _, c := range checks {
+		fmt.Fprintf(&sb, "%s%s\n", padLabel(c.Name+":", labelWidth), c.Detail)
+	}
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Conclusion:", labelWidth), normalityConclusion(checks))
+	return sb.String()
+}
 
-	for _, v := range sortedData {
-		idx := int((v - minVal) / binWidth)
-		if idx >= numBins {
-			idx = numBins - 1
+// outlierScorePenalty is how many score points each percent of IQR outliers costs in the health
+// score, so 10% outliers score 0.
+const outlierScorePenalty = 10.0
+
+// HealthScore is a 0-100 composite of data stability and quality: the plain average of three
+// components, each also on a 0-100 scale.
+type HealthScore struct {
+	Score        float64 // (Stability + Outliers + Normality) / 3
+	Stability    float64 // 100 - CV%, floored at 0; 0 when the CV is undefined
+	Outliers     float64 // 100 - outlierScorePenalty * outlier%, floored at 0
+	Normality    float64 // percent of normalityChecks that pass
+	ChecksPassed int
+	Checks       int
+}
+
+// healthScore computes the health score of data. It requires at least 4 values for the normality checks.
+func healthScore(data []float64, s *Stats) (HealthScore, error) {
+	checks, err := normalityChecks(data, s)
+	if err != nil {
+		return HealthScore{}, err
+	}
+	var h HealthScore
+	if s.CVValid {
+		h.Stability = math.Max(0, 100-s.CV)
+	}
+	outlierPct := float64(len(s.Outliers)) / float64(s.Count) * 100
+	h.Outliers = math.Max(0, 100-outlierScorePenalty*outlierPct)
+	for _, c := range checks {
+		if c.Pass {
+			h.ChecksPassed++
 		}
-		bins[idx]++
 	}
+	h.Checks = len(checks)
+	h.Normality = float64(h.ChecksPassed) / float64(h.Checks) * 100
+	h.Score = (h.Stability + h.Outliers + h.Normality) / 3
+	return h, nil
+}
 
-	maxCount := 0
-	for _, c := range bins {
-		if c > maxCount {
-			maxCount = c
-		}
+// formatHealthScore renders the health score and the inputs behind each component.
+func formatHealthScore(h HealthScore, s *Stats) string {
+	labelWidth := 12 // len("Stability:") + 2
+	cv := "CV N/A"
+	if s.CVValid {
+		cv = fmt.Sprintf("CV %s%%", formatFloat(s.CV))
 	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s / 100\n", padLabel("Score:", labelWidth), formatFloat(h.Score))
+	fmt.Fprintf(&sb, "%s%s (%s)\n", padLabel("Stability:", labelWidth), formatFloat(h.Stability), cv)
+	fmt.Fprintf(&sb, "%s%s (%d of %d values outside the IQR fences)\n", padLabel("Outliers:", labelWidth), formatFloat(h.Outliers), len(s.Outliers), s.Count)
+	fmt.Fprintf(&sb, "%s%s (%d of %d normality checks pass)\n", padLabel("Normality:", labelWidth), formatFloat(h.Normality), h.ChecksPassed, h.Checks)
+	return sb.String()
+}
 
-	blocks := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
-	runes := make([]rune, numBins)
-	for i, c := range bins {
-		if c == 0 {
-			runes[i] = blocks[0]
-		} else {
-			level := (c * 7) / maxCount
-			runes[i] = blocks[level]
+// formatShapeTest formats a shape test. A significant result is described as above or below
+// (e.g. "right skewed" or "left skewed") by the sign of Z, and a non-significant one as consistent
+// with normal (e.g. "symmetry").
+func formatShapeTest(t ShapeTest, above, below, normal string) string {
+	labelWidth := 13 // len("Std. Error:") + 2
+	verdict := fmt.Sprintf("not significant at 5%% (consistent with %s)", normal)
+	if t.Significant {
+		direction := above
+		if t.Z < 0 {
+			direction = below
 		}
+		verdict = fmt.Sprintf("significant at 5%% (%s)", direction)
 	}
-	return string(runes)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Value:", labelWidth), formatFloat(t.Value))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Std. Error:", labelWidth), formatFloat(t.SE))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Z:", labelWidth), formatFloat(t.Z))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Verdict:", labelWidth), verdict)
+	return sb.String()
 }
 
-// generateTrendline creates a Unicode trendline from data in its original input order.
-func generateTrendline(data []float64, numBins int) string {
+// formatShowWork shows how the mean, variance, standard deviation, and skewness are derived,
+// with the formula, the intermediate sums over data, and the result.
+func formatShowWork(data []float64, s *Stats) string {
 	n := len(data)
+	var sumSq, sumCubed float64
+	for _, v := range data {
+		d := v - s.Mean
+		sumSq += d * d
+		sumCubed += d * d * d
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Mean = sum(x)/n = %s/%d = %s\n", formatFloat(s.Sum), n, formatFloat(s.Mean))
 	if n < 2 {
-		return ""
+		sb.WriteString("Variance = sum((x-mean)^2)/(n-1) is undefined for n = 1; reported as 0\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "Variance = sum((x-mean)^2)/(n-1) = %s/%d = %s\n", formatFloat(sumSq), n-1, formatFloat(s.Variance))
+	fmt.Fprintf(&sb, "Std Deviation = sqrt(Variance) = sqrt(%s) = %s\n", formatFloat(s.Variance), formatFloat(s.StdDev))
+	if n < 3 || s.StdDev == 0 {
+		sb.WriteString("Skewness = n/((n-1)(n-2)) * sum((x-mean)^3)/s^3 is undefined for n < 3 or s = 0; reported as 0\n")
+		return sb.String()
 	}
+	fmt.Fprintf(&sb, "Skewness = n/((n-1)(n-2)) * sum((x-mean)^3)/s^3 = %d/(%d*%d) * %s/%s = %s\n",
+		n, n-1, n-2, formatFloat(sumCubed), formatFloat(math.Pow(s.StdDev, 3)), formatFloat(s.Skewness))
+	return sb.String()
+}
 
-	minVal := data[0]
-	maxVal := data[0]
-	for _, v := range data {
-		if v < minVal {
-			minVal = v
-		}
-		if v > maxVal {
-			maxVal = v
+// calculateTrimmedMean computes the mean of sorted data after removing lowPct percent of the values
+// from the low tail and highPct percent from the high tail.
+func calculateTrimmedMean(sortedData []float64, lowPct, highPct float64) (float64, error) {
+	count := len(sortedData)
+	lowCount := int(math.Floor(float64(count) * lowPct / 100.0))
+	highCount := int(math.Floor(float64(count) * highPct / 100.0))
+	remaining := count - lowCount - highCount
+	if remaining < 1 {
+		if lowPct == highPct {
+			return 0, fmt.Errorf("dataset too small (%d values) to trim %.4g%% from each end", count, lowPct)
 		}
+		return 0, fmt.Errorf("dataset too small (%d values) to trim %.4g%% from the low end and %.4g%% from the high end", count, lowPct, highPct)
 	}
-	if minVal == maxVal {
-		return ""
+	var trimSum float64
+	for _, v := range sortedData[lowCount : count-highCount] {
+		trimSum += v
 	}
+	return trimSum / float64(remaining), nil
+}
 
-	// Cap numBins to data length
-	if numBins > n {
-		numBins = n
+// trimmedMeanLabel returns the report label for the trimmed mean, and false when none was computed.
+func trimmedMeanLabel(s *Stats) (string, bool) {
+	switch {
+	case s.TrimmedMeanPct > 0:
+		return fmt.Sprintf("Trimmed Mean (%s%%):", formatFloat(s.TrimmedMeanPct)), true
+	case s.TrimLowPct > 0 || s.TrimHighPct > 0:
+		return fmt.Sprintf("Trimmed Mean (%s%%/%s%%):", formatFloat(s.TrimLowPct), formatFloat(s.TrimHighPct)), true
 	}
+	return "", false
+}
 
-	// Divide data into numBins equal chunks using floating-point boundaries and average each
-	step := float64(n) / float64(numBins)
-	averages := make([]float64, numBins)
-	for i := 0; i < numBins; i++ {
-		start := int(math.Round(float64(i) * step))
-		end := int(math.Round(float64(i+1) * step))
-		if end > n {
-			end = n
-		}
-		if end <= start {
-			end = start + 1
-		}
-		var sum float64
-		for j := start; j < end; j++ {
-			sum += data[j]
-		}
-		averages[i] = sum / float64(end-start)
+// calculateEMA computes the final exponential moving average value for the given span.
+// EMA uses the multiplier α = 2/(span+1), starting from the first data point.
+func calculateEMA(data []float64, span int) float64 {
+	alpha := 2.0 / (float64(span) + 1.0)
+	ema := data[0]
+	for i := 1; i < len(data); i++ {
+		ema = alpha*data[i] + (1-alpha)*ema
 	}
+	return ema
+}
 
-	blocks := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
-	runes := make([]rune, numBins)
-	for i, avg := range averages {
-		normalized := (avg - minVal) / (maxVal - minVal)
-		level := int(math.Round(normalized * 7))
-		if level < 0 {
-			level = 0
+// calculateAutocorrelation computes the sample autocorrelation of data at the given lag, in input order:
+// sum((x[i]-mean)*(x[i+lag]-mean)) / sum((x[i]-mean)^2). The result is in [-1, 1]; ok is false when
+// the data is constant and the coefficient is undefined.
+func calculateAutocorrelation(data []float64, mean float64, lag int) (r float64, ok bool) {
+	var num, den float64
+	for i, v := range data {
+		d := v - mean
+		den += d * d
+		if i+lag < len(data) {
+			num += d * (data[i+lag] - mean)
 		}
-		if level > 7 {
-			level = 7
-		}
-		runes[i] = blocks[level]
 	}
-	return string(runes)
+	if den == 0 {
+		return 0, false
+	}
+	return num / den, true
 }
 
-// calculatePercentile finds the value at a given percentile (p) in sorted data.
-func calculatePercentile(sortedData []float64, p float64) float64 {
-	n := len(sortedData)
-	if n == 0 {
-		return 0
-	}
-	if n == 1 {
-		return sortedData[0]
+// standardNormalQuantile returns the inverse of the standard normal CDF at p (0 < p < 1), using
+// Acklam's rational approximation (relative error below 1.15e-9).
+func standardNormalQuantile(p float64) float64 {
+	a := [6]float64{-3.969683028665376e+01, 2.209460984245205e+02, -2.759285104469687e+02, 1.383577518672690e+02, -3.066479806614716e+01, 2.506628277459239e+00}
+	b := [5]float64{-5.447609879822406e+01, 1.615858368580409e+02, -1.556989798598866e+02, 6.680131188771972e+01, -1.328068155288572e+01}
+	c := [6]float64{-7.784894002430293e-03, -3.223964580411365e-01, -2.400758277161838e+00, -2.549732539343734e+00, 4.374664141464968e+00, 2.938163982698783e+00}
+	d := [4]float64{7.784695709041462e-03, 3.224671290700398e-01, 2.445134137142996e+00, 3.754408661907416e+00}
+	const pLow = 0.02425
+
+	switch {
+	case p <= 0:
+		return math.Inf(-1)
+	case p >= 1:
+		return math.Inf(1)
+	case p < pLow:
+		q := math.Sqrt(-2 * math.Log(p))
+		return (((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) / ((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	case p > 1-pLow:
+		q := math.Sqrt(-2 * math.Log(1-p))
+		return -(((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) / ((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
 	}
+	q := p - 0.5
+	r := q * q
+	return (((((a[0]*r+a[1])*r+a[2])*r+a[3])*r+a[4])*r + a[5]) * q / (((((b[0]*r+b[1])*r+b[2])*r+b[3])*r+b[4])*r + 1)
+}
 
-	rank := p * float64(n-1)
-	lowerIndex := math.Floor(rank)
-	upperIndex := math.Ceil(rank)
+// normalQuantile returns the value at cumulative probability p (0 < p < 1) of a normal distribution
+// with the given mean and standard deviation.
+func normalQuantile(p, mean, stddev float64) float64 {
+	return mean + stddev*standardNormalQuantile(p)
+}
 
-	if lowerIndex == upperIndex {
-		return sortedData[int(rank)]
+// formatTheoreticalPercentile compares the P-th percentile under a normal model fitted to s (its mean
+// and standard deviation) with the empirical percentile.
+func formatTheoreticalPercentile(p float64, s *Stats, empirical float64) string {
+	theoretical := normalQuantile(p/100.0, s.Mean, s.StdDev)
+	name := "p" + formatFloat(p)
+	labelWidth := len("Normal Model ("+name+"):") + 1
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Normal Model ("+name+"):", labelWidth), formatFloat(theoretical))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Empirical ("+name+"):", labelWidth), formatFloat(empirical))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Difference:", labelWidth), formatSigned(empirical-theoretical))
+	return sb.String()
+}
+
+// pearsonCorrelation computes the Pearson correlation coefficient of two equal-length series.
+// ok is false when either series is constant.
+func pearsonCorrelation(x, y []float64) (r float64, ok bool) {
+	n := float64(len(x))
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
 	}
+	meanX /= n
+	meanY /= n
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0, false
+	}
+	return sxy / math.Sqrt(sxx*syy), true
+}
 
-	weight := rank - lowerIndex
-	return sortedData[int(lowerIndex)]*(1-weight) + sortedData[int(upperIndex)]*weight
+// firstDifferenceRatio returns the mean absolute first difference of data, in input order, divided
+// by |mean|: a simple roughness measure that is 0 for a constant series and grows as consecutive
+// values jump relative to their level. It requires at least 2 values and a mean that is not near zero.
+func firstDifferenceRatio(data []float64, mean float64) (float64, error) {
+	if len(data) < 2 {
+		return 0, fmt.Errorf("volatility requires at least 2 values, got %d", len(data))
+	}
+	if math.Abs(mean) < 1e-10 {
+		return 0, fmt.Errorf("volatility is undefined when the mean is near zero")
+	}
+	var sumAbsDiff float64
+	for i := 1; i < len(data); i++ {
+		sumAbsDiff += math.Abs(data[i] - data[i-1])
+	}
+	return sumAbsDiff / float64(len(data)-1) / math.Abs(mean), nil
 }
 
-// calculateSkewness computes the adjusted Fisher-Pearson standardized moment coefficient.
-func calculateSkewness(data []float64, mean, stdDev float64) float64 {
-	n := float64(len(data))
-	if n < 3 || stdDev == 0 {
-		return 0 // Skewness is not defined for less than 3 points or zero std dev
+// TrendFit describes how well the least-squares line over input position fits the data.
+type TrendFit struct {
+	Slope       float64
+	Intercept   float64
+	RMSE        float64 // sqrt(SSE / n)
+	ResidualSD  float64 // sqrt(SSE / (n - 2)), the standard error of the regression
+	MaxResidual float64 // residual with the largest magnitude, keeping its sign
+	MaxIndex    int     // 1-based input position of MaxResidual
+}
+
+// trendFitQuality fits data, in input order, against its positions (see detrend) and summarizes the
+// residuals. It requires at least 3 values, since the line itself uses 2 degrees of freedom.
+func trendFitQuality(data []float64) (TrendFit, error) {
+	if len(data) < 3 {
+		return TrendFit{}, fmt.Errorf("fit quality requires at least 3 values, got %d", len(data))
 	}
+	residuals, slope, intercept := detrend(data)
+	fit := TrendFit{Slope: slope, Intercept: intercept}
+	var sse float64
+	for i, r := range residuals {
+		sse += r * r
+		if math.Abs(r) > math.Abs(fit.MaxResidual) || i == 0 {
+			fit.MaxResidual, fit.MaxIndex = r, i+1
+		}
+	}
+	n := float64(len(data))
+	fit.RMSE = math.Sqrt(sse / n)
+	fit.ResidualSD = math.Sqrt(sse / (n - 2))
+	return fit, nil
+}
 
-	var sumOfCubedDeviations float64
-	for _, v := range data {
-		sumOfCubedDeviations += math.Pow(v-mean, 3)
+// formatTrendFit renders the fitted line and its residual statistics.
+func formatTrendFit(f TrendFit) string {
+	labelWidth := 15 // len("Max Residual:") + 2
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s + %s × position\n", padLabel("Line:", labelWidth), formatFloat(f.Intercept), formatFloat(f.Slope))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("RMSE:", labelWidth), formatFloat(f.RMSE))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Residual SD:", labelWidth), formatFloat(f.ResidualSD))
+	fmt.Fprintf(&sb, "%s%s (value %d)\n", padLabel("Max Residual:", labelWidth), formatSigned(f.MaxResidual), f.MaxIndex)
+	return sb.String()
+}
+
+// linearFit returns the ordinary least-squares line y = intercept + slope*x through two equal-length
+// series. ok is false when x is constant, so the slope is undefined.
+func linearFit(x, y []float64) (slope, intercept float64, ok bool) {
+	n := float64(len(x))
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+	var sxy, sxx float64
+	for i := range x {
+		dx := x[i] - meanX
+		sxy += dx * (y[i] - meanY)
+		sxx += dx * dx
 	}
+	if sxx == 0 {
+		return 0, meanY, false
+	}
+	slope = sxy / sxx
+	return slope, meanY - slope*meanX, true
+}
 
-	// Formula for sample skewness
-	return (n / ((n - 1) * (n - 2))) * (sumOfCubedDeviations / math.Pow(stdDev, 3))
+// indexTrend fits data, in input order, against its positions 0..n-1 and returns the line's slope
+// and intercept. A single value has slope 0 and intercept equal to the value.
+func indexTrend(data []float64) (slope, intercept float64) {
+	x := make([]float64, len(data))
+	for i := range x {
+		x[i] = float64(i)
+	}
+	slope, intercept, _ = linearFit(x, data)
+	return slope, intercept
 }
 
-// calculateKurtosis computes the sample excess kurtosis.
-func calculateKurtosis(data []float64, mean, stdDev float64) float64 {
-	n := float64(len(data))
-	if n < 4 || stdDev == 0 {
-		return 0
+// calculateQQCorrelation computes the QQ-plot correlation coefficient: the correlation between the
+// sorted data and the standard normal quantiles at Blom's plotting positions (i - 0.375)/(n + 0.25).
+// Values near 1 indicate the data is consistent with a normal distribution. ok is false for fewer
+// than three values or constant data.
+func calculateQQCorrelation(data []float64) (r float64, ok bool) {
+	n := len(data)
+	if n < 3 {
+		return 0, false
 	}
-	var sumOfFourthDeviations float64
-	for _, v := range data {
-		sumOfFourthDeviations += math.Pow((v-mean)/stdDev, 4)
+	sorted := make([]float64, n)
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	theoretical := make([]float64, n)
+	for i := range theoretical {
+		theoretical[i] = standardNormalQuantile((float64(i+1) - 0.375) / (float64(n) + 0.25))
 	}
-	// Excess kurtosis using the sample formula
-	return (n*(n+1))/((n-1)*(n-2)*(n-3))*sumOfFourthDeviations - 3*(n-1)*(n-1)/((n-2)*(n-3))
+	return pearsonCorrelation(sorted, theoretical)
 }
 
-// calculateEMA computes the final exponential moving average value for the given span.
-// EMA uses the multiplier α = 2/(span+1), starting from the first data point.
-func calculateEMA(data []float64, span int) float64 {
-	alpha := 2.0 / (float64(span) + 1.0)
-	ema := data[0]
+// calculateEMASeries computes the exponential moving average at each position of data, in input
+// order, with smoothing factor alpha: ema[i] = alpha*data[i] + (1-alpha)*ema[i-1], starting from the
+// first data point.
+func calculateEMASeries(data []float64, alpha float64) []float64 {
+	if len(data) == 0 {
+		return nil
+	}
+	series := make([]float64, len(data))
+	series[0] = data[0]
 	for i := 1; i < len(data); i++ {
-		ema = alpha*data[i] + (1-alpha)*ema
+		series[i] = alpha*data[i] + (1-alpha)*series[i-1]
 	}
-	return ema
+	return series
+}
+
+// isNearConstant reports whether the data is effectively constant: all values are equal, or the
+// standard deviation relative to the magnitude of the mean is below threshold.
+func isNearConstant(s *Stats, threshold float64) bool {
+	if s.Min == s.Max {
+		return true
+	}
+	if math.Abs(s.Mean) < 1e-10 {
+		return false
+	}
+	return s.StdDev/math.Abs(s.Mean) < threshold
 }
 
 // interpretKurtosis provides a human-readable label for a kurtosis value.
@@ -615,6 +4989,15 @@ func interpretCV(cv float64) string {
 	return "High Variability"
 }
 
+// percentOfMean expresses value as a percentage of |mean|.
+// It returns false when the mean is near zero, matching the CV cutoff.
+func percentOfMean(value, mean float64) (float64, bool) {
+	if math.Abs(mean) < 1e-10 {
+		return 0, false
+	}
+	return value / math.Abs(mean) * 100, true
+}
+
 // formatFloat formats a float64 without scientific notation, trimming unnecessary trailing zeros.
 func formatFloat(v float64) string {
 	if v == math.Trunc(v) {
@@ -623,6 +5006,10 @@ func formatFloat(v float64) string {
 	s := strconv.FormatFloat(v, 'f', 4, 64)
 	s = strings.TrimRight(s, "0")
 	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		// tiny negative values such as a detrended sum round to zero
+		return "0"
+	}
 	return s
 }
 
@@ -638,6 +5025,16 @@ func formatFloatSlice(values []float64) string {
 	return "[" + strings.Join(parts, " ") + "]"
 }
 
+// formatFixedSlice formats a slice of float64 values with a fixed number of decimal places,
+// keeping trailing zeros, e.g. [50.00 62.50] at precision 2.
+func formatFixedSlice(values []float64, precision int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.FormatFloat(v, 'f', precision, 64)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
 // interpretSkewness provides a human-readable label for a skewness value.
 func interpretSkewness(s float64) string {
 	absS := math.Abs(s)
@@ -656,6 +5053,179 @@ func interpretSkewness(s float64) string {
 	return "Highly Left Skewed"
 }
 
+// formatSortedValues returns the values in ascending order, space-separated and wrapped at width.
+// A precision of 0 or more prints that many decimal places; a negative precision trims trailing zeros.
+func formatSortedValues(data []float64, width, precision int) string {
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+	parts := make([]string, len(sorted))
+	for i, v := range sorted {
+		if precision >= 0 {
+			parts[i] = strconv.FormatFloat(v, 'f', precision, 64)
+		} else {
+			parts[i] = formatFloat(v)
+		}
+	}
+	return wrapText(strings.Join(parts, " "), width)
+}
+
+// formatDuplicates lists each duplicated value with its count, one per line.
+func formatDuplicates(dupes []ValueCount) string {
+	if len(dupes) == 0 {
+		return "None\n"
+	}
+	valueWidth := 0
+	for _, d := range dupes {
+		valueWidth = max(valueWidth, len(formatFloat(d.Value)))
+	}
+	var sb strings.Builder
+	for _, d := range dupes {
+		fmt.Fprintf(&sb, "%-*s x%d\n", valueWidth, formatFloat(d.Value), d.Count)
+	}
+	return sb.String()
+}
+
+// VarianceContributor is a value with its squared deviation from the mean and its percent share
+// of the total sum of squares.
+type VarianceContributor struct {
+	Value      float64
+	SquaredDev float64
+	Share      float64
+}
+
+// topVarianceContributors returns the n values with the largest squared deviation (x - mean)^2,
+// largest first, with ties kept in input order. It returns nil when the sum of squares is 0.
+func topVarianceContributors(data []float64, mean float64, n int) []VarianceContributor {
+	contributors := make([]VarianceContributor, len(data))
+	total := 0.0
+	for i, v := range data {
+		d := (v - mean) * (v - mean)
+		contributors[i] = VarianceContributor{Value: v, SquaredDev: d}
+		total += d
+	}
+	if total == 0 {
+		return nil
+	}
+	sort.SliceStable(contributors, func(i, j int) bool { return contributors[i].SquaredDev > contributors[j].SquaredDev })
+	contributors = contributors[:min(n, len(contributors))]
+	for i := range contributors {
+		contributors[i].Share = 100 * contributors[i].SquaredDev / total
+	}
+	return contributors
+}
+
+// formatVarianceContributors builds an aligned table of the top variance contributors.
+func formatVarianceContributors(contributors []VarianceContributor) string {
+	rows := [][]string{{"Value", "(x-mean)^2", "Share"}}
+	for _, c := range contributors {
+		rows = append(rows, []string{formatFloat(c.Value), formatFloat(c.SquaredDev), formatFloat(c.Share) + "%"})
+	}
+	return formatTable(rows)
+}
+
+// latencyPercentiles are the percentiles reported by the -latency table.
+var latencyPercentiles = []float64{50, 75, 90, 95, 99, 99.9}
+
+// formatLatencyTable builds an aligned table of the latency percentiles, reusing the percentiles
+// already in s and computing the others from data with the given percentile method.
+func formatLatencyTable(s *Stats, data []float64, percentile percentileFunc) string {
+	known := map[float64]float64{50: s.Median, 75: s.Q3, 95: s.P95, 99: s.P99}
+	var sortedData []float64
+	labels := make([]string, len(latencyPercentiles))
+	values := make([]string, len(latencyPercentiles))
+	labelWidth, valueWidth := 0, 0
+	for i, p := range latencyPercentiles {
+		v, ok := known[p]
+		if !ok {
+			if sortedData == nil {
+				sortedData = make([]float64, len(data))
+				copy(sortedData, data)
+				sort.Float64s(sortedData)
+			}
+			v = percentile(sortedData, p/100.0)
+		}
+		labels[i] = "p" + formatFloat(p) + ":"
+		values[i] = formatFloat(v)
+		labelWidth = max(labelWidth, len(labels[i]))
+		valueWidth = max(valueWidth, len(values[i]))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- Latency Percentiles ---\n")
+	for i := range labels {
+		fmt.Fprintf(&sb, "%-*s %*s\n", labelWidth, labels[i], valueWidth, values[i])
+	}
+	return sb.String()
+}
+
+// explainStats builds a plain-English interpretation of the spread, shape, and outliers of the data.
+func explainStats(s *Stats) string {
+	var parts []string
+
+	parts = append(parts, fmt.Sprintf("The typical value is %s (the median), and the middle half of the data lies between %s and %s, a spread of %s (the IQR).",
+		formatFloat(s.Median), formatFloat(s.Q1), formatFloat(s.Q3), formatFloat(s.IQR)))
+	parts = append(parts, fmt.Sprintf("Values differ from the mean of %s by about %s on average (the standard deviation).",
+		formatFloat(s.Mean), formatFloat(s.StdDev)))
+
+	if s.CVValid {
+		parts = append(parts, fmt.Sprintf("Relative to the mean, that is a coefficient of variation of %s%%, which indicates %s.",
+			formatFloat(s.CV), strings.ToLower(interpretCV(s.CV))))
+	} else {
+		parts = append(parts, "The coefficient of variation is not meaningful because the mean is near zero.")
+	}
+
+	skew := strings.ToLower(interpretSkewness(s.Skewness))
+	switch {
+	case math.Abs(s.Skewness) < 0.5:
+		parts = append(parts, fmt.Sprintf("The distribution is %s (skewness %s), so the mean and median tell a similar story.",
+			skew, formatFloat(s.Skewness)))
+	case s.Skewness > 0:
+		parts = append(parts, fmt.Sprintf("The distribution is %s (skewness %s): a tail of larger values pulls the mean above most of the data.",
+			skew, formatFloat(s.Skewness)))
+	default:
+		parts = append(parts, fmt.Sprintf("The distribution is %s (skewness %s): a tail of smaller values pulls the mean below most of the data.",
+			skew, formatFloat(s.Skewness)))
+	}
+
+	kurt := interpretKurtosis(s.Kurtosis)
+	name, desc, _ := strings.Cut(kurt, " - ")
+	parts = append(parts, fmt.Sprintf("Its tails are %s (%s, kurtosis %s).", desc, strings.ToLower(name), formatFloat(s.Kurtosis)))
+
+	switch len(s.Outliers) {
+	case 0:
+		parts = append(parts, "No values fall outside the IQR outlier fences.")
+	case 1:
+		parts = append(parts, fmt.Sprintf("One value (%s) falls outside the IQR outlier fences and may deserve a closer look.",
+			formatFloat(s.Outliers[0])))
+	default:
+		parts = append(parts, fmt.Sprintf("%d values %s fall outside the IQR outlier fences and may deserve a closer look.",
+			len(s.Outliers), formatFloatSlice(s.Outliers)))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// wrapText breaks text into lines of at most width characters at word boundaries.
+func wrapText(text string, width int) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line == "" {
+			line = word
+		} else if len([]rune(line))+1+len([]rune(word)) > width {
+			lines = append(lines, line)
+			line = word
+		} else {
+			line += " " + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // padLabel pads a label to at least labelWidth characters, ensuring at least one trailing space.
 func padLabel(label string, labelWidth int) string {
 	padded := fmt.Sprintf("%-*s", labelWidth, label)
@@ -665,55 +5235,340 @@ func padLabel(label string, labelWidth int) string {
 	return padded
 }
 
-// printStats displays the results in a readable format.
-func printStats(s *Stats, labelWidth int) {
+// templateFuncs are the helper functions available to -template, e.g. {{format .Mean}}.
+var templateFuncs = template.FuncMap{
+	"format":      formatFloat,
+	"formatSlice": formatFloatSlice,
+}
+
+// renderTemplate executes a Go text/template against s and returns the result.
+func renderTemplate(text string, s *Stats) (string, error) {
+	tmpl, err := template.New("stats").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %v", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, s); err != nil {
+		return "", fmt.Errorf("executing template: %v", err)
+	}
+	return sb.String(), nil
+}
+
+// jsonlRecord is a single JSON Lines entry: a timestamped snapshot of the computed statistics.
+type jsonlRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source,omitempty"` // input file the statistics came from (-per-file)
+	*Stats
+	// Shadows Stats.CustomPercentiles, since JSON object keys cannot be float64
+	CustomPercentiles map[string]float64 `json:",omitempty"`
+}
+
+// customPercentileKeys returns s.CustomPercentiles keyed by the formatted percentile, or nil when
+// there are none.
+func customPercentileKeys(s *Stats) map[string]float64 {
+	if len(s.CustomPercentiles) == 0 {
+		return nil
+	}
+	keyed := make(map[string]float64, len(s.CustomPercentiles))
+	for p, v := range s.CustomPercentiles {
+		keyed[formatFloat(p)] = v
+	}
+	return keyed
+}
+
+// writeJSONL writes s as one compact JSON object followed by a newline, stamped with ts and, when
+// source is not empty, the name of the input it was computed from.
+func writeJSONL(w io.Writer, s *Stats, source string, ts time.Time) error {
+	record := jsonlRecord{Timestamp: ts, Source: source, Stats: s, CustomPercentiles: customPercentileKeys(s)}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", line)
+	return err
+}
+
+// summaryRecord is the -summary-json object: the statistics plus the interpretation labels of the
+// text report, so consumers need not re-implement the thresholds.
+type summaryRecord struct {
+	*Stats
+	// Shadows Stats.CustomPercentiles, since JSON object keys cannot be float64
+	CustomPercentiles      map[string]float64 `json:",omitempty"`
+	SkewnessInterpretation string
+	KurtosisInterpretation string
+	CVInterpretation       string `json:",omitempty"` // empty when the CV is undefined (mean near zero)
+}
+
+// writeSummaryJSON writes s and its interpretation labels as one compact JSON object followed by a newline.
+func writeSummaryJSON(w io.Writer, s *Stats) error {
+	record := summaryRecord{
+		Stats:                  s,
+		CustomPercentiles:      customPercentileKeys(s),
+		SkewnessInterpretation: interpretSkewness(s.Skewness),
+		KurtosisInterpretation: interpretKurtosis(s.Kurtosis),
+	}
+	if s.CVValid {
+		record.CVInterpretation = interpretCV(s.CV)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", line)
+	return err
+}
+
+// writeHistogramJSON writes the histogram bins of sorted data as a JSON array of {lower, upper, count}
+// objects. When bins is nil (constant data), the data is reported as a single bin.
+func writeHistogramJSON(w io.Writer, bins []HistogramBin, sortedData []float64) error {
+	if bins == nil {
+		bins = []HistogramBin{}
+		if n := len(sortedData); n > 0 {
+			bins = append(bins, HistogramBin{Lower: sortedData[0], Upper: sortedData[n-1], Count: n})
+		}
+	}
+	out, err := json.Marshal(bins)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+// histogramDensities returns the probability density of each bin, count / (n * width), where n is
+// the total count across the bins, so that density times width sums to 1. Weighted bins use their
+// summed weight in place of the count. Zero-width bins have density 0.
+func histogramDensities(bins []HistogramBin) []float64 {
+	mass := func(b HistogramBin) float64 { return float64(b.Count) }
+	for _, b := range bins {
+		if b.Weight != nil {
+			mass = func(b HistogramBin) float64 { return *b.Weight }
+			break
+		}
+	}
+	total := 0.0
+	for _, b := range bins {
+		total += mass(b)
+	}
+	densities := make([]float64, len(bins))
+	for i, b := range bins {
+		if width := b.Upper - b.Lower; width > 0 && total > 0 {
+			densities[i] = mass(b) / (total * width)
+		}
+	}
+	return densities
+}
+
+// deciles returns the nine deciles P10, P20, ..., P90 of sorted data.
+func deciles(sortedData []float64, percentile percentileFunc) []float64 {
+	values := make([]float64, 9)
+	for i := range values {
+		values[i] = percentile(sortedData, float64(i+1)/10)
+	}
+	return values
+}
+
+// reportLine is one line of a report: a label and its value, or free text when label is empty.
+type reportLine struct {
+	label string
+	value string
+}
+
+// report accumulates the lines of a report so they can be laid out together.
+type report struct {
+	lines []reportLine
+}
+
+// row adds a labeled value. The label includes its trailing colon.
+func (r *report) row(label, value string) {
+	r.lines = append(r.lines, reportLine{label: label, value: value})
+}
+
+// text adds a line that is printed as is, such as a section banner.
+func (r *report) text(line string) {
+	r.lines = append(r.lines, reportLine{value: line})
+}
+
+// render lays out the report. By default each label is padded to labelWidth. When align is true,
+// the labels are padded to the longest one so the colons line up, and each value's leading number
+// is right-aligned in a column as wide as the widest such number.
+func (r *report) render(labelWidth int, align bool) string {
+	var sb strings.Builder
+	if !align {
+		for _, line := range r.lines {
+			if line.label == "" {
+				sb.WriteString(line.value + "\n")
+			} else {
+				sb.WriteString(padLabel(line.label, labelWidth) + line.value + "\n")
+			}
+		}
+		return sb.String()
+	}
+
+	nameWidth, numberWidth := 0, 0
+	for _, line := range r.lines {
+		if line.label == "" {
+			continue
+		}
+		nameWidth = max(nameWidth, len([]rune(strings.TrimSuffix(line.label, ":"))))
+		if number, _, ok := leadingNumber(line.value); ok {
+			numberWidth = max(numberWidth, len(number))
+		}
+	}
+	for _, line := range r.lines {
+		if line.label == "" {
+			sb.WriteString(line.value + "\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "%-*s : ", nameWidth, strings.TrimSuffix(line.label, ":"))
+		if number, rest, ok := leadingNumber(line.value); ok {
+			fmt.Fprintf(&sb, "%*s%s\n", numberWidth, number, rest)
+		} else {
+			sb.WriteString(line.value + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// leadingNumber splits value into its first space-separated token and the remainder, reporting
+// whether that token is a number (optionally followed by %).
+func leadingNumber(value string) (number, rest string, ok bool) {
+	number, rest = value, ""
+	if i := strings.IndexByte(value, ' '); i >= 0 {
+		number, rest = value[:i], value[i:]
+	}
+	if _, err := strconv.ParseFloat(strings.TrimSuffix(number, "%"), 64); err != nil {
+		return "", "", false
+	}
+	return number, rest, true
+}
+
+// formatDigestQuantiles lists the approximate quartiles and median estimated by a t-digest,
+// along with its size, which bounds memory use.
+func formatDigestQuantiles(d *tDigest) string {
+	labelWidth := 20 // len("Quartile 3 (~p75):") + 2
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Quartile 1 (~p25):", labelWidth), formatFloat(d.Quantile(0.25)))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Median (~p50):", labelWidth), formatFloat(d.Quantile(0.5)))
+	fmt.Fprintf(&sb, "%s%s\n", padLabel("Quartile 3 (~p75):", labelWidth), formatFloat(d.Quantile(0.75)))
+	fmt.Fprintf(&sb, "%s%d (compression %s)\n", padLabel("Centroids:", labelWidth), d.Centroids(), formatFloat(d.compression))
+	return sb.String()
+}
+
+// printExtremes displays the Count, Min, and Max computed by the -extremes fast path.
+func printExtremes(s *Stats) {
+	labelWidth := 7 // len("Count:") + 1
 	fmt.Println("--- Descriptive Statistics ---")
 	fmt.Printf("%s%d\n", padLabel("Count:", labelWidth), s.Count)
-	fmt.Printf("%s%s\n", padLabel("Sum:", labelWidth), formatFloat(s.Sum))
 	fmt.Printf("%s%s\n", padLabel("Min:", labelWidth), formatFloat(s.Min))
 	fmt.Printf("%s%s\n", padLabel("Max:", labelWidth), formatFloat(s.Max))
-	fmt.Println("\n--- Measures of Central Tendency ---")
-	fmt.Printf("%s%s\n", padLabel("Mean:", labelWidth), formatFloat(s.Mean))
-	if s.TrimmedMeanPct > 0 {
-		label := fmt.Sprintf("Trimmed Mean (%s%%):", formatFloat(s.TrimmedMeanPct))
-		fmt.Printf("%s%s\n", padLabel(label, labelWidth), formatFloat(s.TrimmedMean))
+}
+
+// printStats displays the results in a readable format.
+func printStats(s *Stats, opts ReportOptions, labelWidth int) {
+	fmt.Print(formatReport(s, opts, labelWidth))
+}
+
+// formatReport lays out the statistics as labeled lines grouped into sections.
+func formatReport(s *Stats, opts ReportOptions, labelWidth int) string {
+	var r report
+	header := func(banner string) {
+		if !opts.NoHeader {
+			r.text(banner)
+		}
+	}
+	// spread formats a spread statistic, appending its percent of the mean for -relative.
+	spread := func(v float64) string {
+		if opts.ShowRelative {
+			if pct, ok := percentOfMean(v, s.Mean); ok {
+				return fmt.Sprintf("%s (%s%% of mean)", formatFloat(v), formatFloat(pct))
+			}
+		}
+		return formatFloat(v)
+	}
+	header("--- Descriptive Statistics ---")
+	r.row("Count:", strconv.Itoa(s.Count))
+	if opts.ShowMissing {
+		r.row("Skipped/missing:", strconv.Itoa(s.MissingCount))
+	}
+	r.row("Sum:", formatFloat(s.Sum))
+	r.row("Min:", formatFloat(s.Min))
+	r.row("Max:", formatFloat(s.Max))
+	if opts.ShowRelative {
+		r.row("Range:", spread(s.Max-s.Min))
+	}
+	header("\n--- Measures of Central Tendency ---")
+	r.row("Mean:", formatFloat(s.Mean))
+	if label, ok := trimmedMeanLabel(s); ok {
+		r.row(label, formatFloat(s.TrimmedMean))
 	}
 	if s.EMASpan > 0 {
 		label := fmt.Sprintf("EMA (span %d):", s.EMASpan)
-		fmt.Printf("%s%s\n", padLabel(label, labelWidth), formatFloat(s.EMA))
+		r.row(label, formatFloat(s.EMA))
+	}
+	r.row("Median (p50):", formatFloat(s.Median))
+	if s.HasWeights {
+		r.row("Weighted Median:", formatFloat(s.WeightedMedian))
 	}
-	fmt.Printf("%s%s\n", padLabel("Median (p50):", labelWidth), formatFloat(s.Median))
+	r.row("Trimean:", formatFloat(s.Trimean))
 
-	switch len(s.Mode) {
-	case 0:
-		fmt.Printf("%s%s\n", padLabel("Mode:", labelWidth), "None")
-	case 1:
+	// values formats Mode and outlier lists, honoring -precision.
+	values := func(v []float64) string {
+		if opts.FixedPrecision {
+			return formatFixedSlice(v, opts.Precision)
+		}
+		return formatFloatSlice(v)
+	}
+
+	switch {
+	case s.ModeTolerance > 0:
+		label := fmt.Sprintf("Mode (tol %s):", formatFloat(s.ModeTolerance))
+		if s.ClusterModeCount > 1 {
+			r.row(label, fmt.Sprintf("%s (%d values)", formatFloat(s.ClusterMode), s.ClusterModeCount))
+		} else {
+			r.row(label, "None")
+		}
+	case len(s.Mode) == 0:
+		r.row("Mode:", "None")
+	case len(s.Mode) == 1:
 		// If there's only one mode, print it as a clean number.
-		fmt.Printf("%s%s\n", padLabel("Mode:", labelWidth), formatFloat(s.Mode[0]))
+		r.row("Mode:", strings.Trim(values(s.Mode), "[]"))
 	default:
 		// If there are multiple modes, label it and print the slice.
-		fmt.Printf("%s%s\n", padLabel("Mode (multi):", labelWidth), formatFloatSlice(s.Mode))
+		r.row("Mode (multi):", values(s.Mode))
+	}
+	if s.ModalBin != nil {
+		b := s.ModalBin
+		r.row("Modal Bin:", fmt.Sprintf("%s (range %s to %s, count %d)", formatFloat((b.Lower+b.Upper)/2), formatFloat(b.Lower), formatFloat(b.Upper), b.Count))
 	}
 
-	fmt.Println("\n--- Measures of Spread & Distribution ---")
-	fmt.Printf("%s%s\n", padLabel("Std Deviation:", labelWidth), formatFloat(s.StdDev))
-	fmt.Printf("%s%s\n", padLabel("Variance:", labelWidth), formatFloat(s.Variance))
+	header("\n--- Measures of Spread & Distribution ---")
+	if s.NearConstant {
+		r.text("WARNING: data is near-constant; CV, skewness, and kurtosis may be misleading")
+	}
+	r.row("Std Deviation:", spread(s.StdDev))
+	if s.StdDevCILevel > 0 {
+		label := fmt.Sprintf("Std Dev (%s%% CI):", formatFloat(s.StdDevCILevel))
+		r.row(label, fmt.Sprintf("[%s, %s]", formatFloat(s.StdDevCILower), formatFloat(s.StdDevCIUpper)))
+	}
+	r.row("Variance:", formatFloat(s.Variance))
+	r.row("RMS:", formatFloat(s.RMS))
 	if !s.CVValid {
-		fmt.Printf("%s%s\n", padLabel("CV:", labelWidth), "N/A - mean near zero")
+		r.row("CV:", "N/A - mean near zero")
 	} else {
 		cvStr := fmt.Sprintf("%s%% (%s)", formatFloat(s.CV), interpretCV(s.CV))
 		if s.HasNegativeData {
 			cvStr += " WARNING: data set contains negative data"
 		}
-		fmt.Printf("%s%s\n", padLabel("CV:", labelWidth), cvStr)
+		r.row("CV:", cvStr)
 	}
-	fmt.Printf("%s%s\n", padLabel("Quartile 1 (p25):", labelWidth), formatFloat(s.Q1))
-	fmt.Printf("%s%s\n", padLabel("Quartile 3 (p75):", labelWidth), formatFloat(s.Q3))
+	r.row("Quartile 1 (p25):", formatFloat(s.Q1))
+	r.row("Quartile 3 (p75):", formatFloat(s.Q3))
 	star := ""
 	if s.TrimDatasetPct > 0 {
 		star = "*"
 	}
-	allPercentiles := map[float64]float64{95: s.P95, 99: s.P99}
+	allPercentiles := map[float64]float64{1: s.P1, 5: s.P5, 10: s.P10, 95: s.P95, 99: s.P99}
 	for k, v := range s.CustomPercentiles {
 		allPercentiles[k] = v
 	}
@@ -724,34 +5579,102 @@ func printStats(s *Stats, labelWidth int) {
 	sort.Float64s(pctKeys)
 	for _, k := range pctKeys {
 		label := fmt.Sprintf("Percentile (p%s)%s:", formatFloat(k), star)
-		fmt.Printf("%s%s\n", padLabel(label, labelWidth), formatFloat(allPercentiles[k]))
+		r.row(label, formatFloat(allPercentiles[k]))
+	}
+	r.row("IQR:", spread(s.IQR))
+	if s.TrimmedRangePct > 0 {
+		label := fmt.Sprintf("Trimmed Range (%s%%)%s:", formatFloat(s.TrimmedRangePct), star)
+		r.row(label, formatFloat(s.TrimmedRange))
+	}
+	if opts.ShowMAD {
+		r.row("MAD:", formatFloat(s.MAD))
+		r.row("MAD (scaled):", formatFloat(s.ScaledMAD))
+	}
+	r.row("Skewness"+star+":", fmt.Sprintf("%s (%s)", formatFloat(s.Skewness), interpretSkewness(s.Skewness)))
+	r.row("Kurtosis"+star+":", fmt.Sprintf("%s (%s)", formatFloat(s.Kurtosis), interpretKurtosis(s.Kurtosis)))
+	if s.AutocorrLag > 0 {
+		label := fmt.Sprintf("Autocorr (lag %d):", s.AutocorrLag)
+		if s.AutocorrValid {
+			r.row(label, formatFloat(s.Autocorr))
+		} else {
+			r.row(label, "N/A (constant data)")
+		}
+	}
+	if opts.ShowTrendSlope {
+		r.row("Trend Slope:", formatFloat(s.TrendSlope))
+	}
+	if opts.ShowVolatility {
+		r.row("Volatility:", formatFloat(s.Volatility))
+	}
+	if opts.ShowQQ {
+		if s.QQValid {
+			r.row("QQ Correlation"+star+":", formatFloat(s.QQCorrelation))
+		} else {
+			r.row("QQ Correlation"+star+":", "N/A (needs 3+ distinct values)")
+		}
 	}
-	fmt.Printf("%s%s\n", padLabel("IQR:", labelWidth), formatFloat(s.IQR))
-	fmt.Printf("%s%s (%s)\n", padLabel("Skewness"+star+":", labelWidth), formatFloat(s.Skewness), interpretSkewness(s.Skewness))
-	fmt.Printf("%s%s (%s)\n", padLabel("Kurtosis"+star+":", labelWidth), formatFloat(s.Kurtosis), interpretKurtosis(s.Kurtosis))
 	if len(s.Outliers) > 0 {
-		fmt.Printf("%s%s\n", padLabel("Outliers"+star+":", labelWidth), formatFloatSlice(s.Outliers))
+		r.row("Outliers"+star+":", values(s.Outliers))
+		r.row("Outlier Classes"+star+":", fmt.Sprintf("%d mild, %d extreme", len(s.MildOutliers), len(s.ExtremeOutliers)))
 	} else {
-		fmt.Printf("%s%s\n", padLabel("Outliers"+star+":", labelWidth), "None")
+		r.row("Outliers"+star+":", "None")
 	}
 	if s.ZScoreThreshold > 0 {
 		label := fmt.Sprintf("Z-Outliers (Z>%s)%s:", formatFloat(s.ZScoreThreshold), star)
 		if len(s.ZScoreOutliers) > 0 {
-			fmt.Printf("%s%s\n", padLabel(label, labelWidth), formatFloatSlice(s.ZScoreOutliers))
+			r.row(label, values(s.ZScoreOutliers))
 		} else {
-			fmt.Printf("%s%s\n", padLabel(label, labelWidth), "None")
+			r.row(label, "None")
 		}
 	}
-	if s.Histogram != "" || s.Trendline != "" {
-		fmt.Printf("\n--- Distribution ---\n")
+	if s.ConsensusModZ > 0 {
+		label := fmt.Sprintf("Consensus Outliers (M>%s)%s:", formatFloat(s.ConsensusModZ), star)
+		if len(s.ConsensusOutliers) > 0 {
+			r.row(label, values(s.ConsensusOutliers))
+		} else {
+			r.row(label, "None")
+		}
+	}
+	if s.Histogram != "" || s.Trendline != "" || s.EMATrendline != "" || s.CDFSparkline != "" {
+		header("\n--- Distribution ---")
 		if s.Histogram != "" {
-			fmt.Printf("%s%s\n", padLabel("Histogram:", labelWidth), s.Histogram)
+			label := "Histogram:"
+			switch {
+			case opts.HistLog && opts.HistInterp:
+				label = "Histogram (log, smoothed):"
+			case opts.HistLog:
+				label = "Histogram (log):"
+			case opts.HistInterp:
+				label = "Histogram (smoothed):"
+			case opts.HistEqualFreq:
+				label = "Histogram (equal-freq):"
+			}
+			if s.HistClipped > 0 {
+				noun := "outliers"
+				if s.HistClipped == 1 {
+					noun = "outlier"
+				}
+				r.row(label, fmt.Sprintf("%s (%d %s excluded)", s.Histogram, s.HistClipped, noun))
+			} else {
+				r.row(label, s.Histogram)
+			}
+			if s.QuartileMarkers != "" {
+				r.row("Quartiles:", s.QuartileMarkers)
+			}
 		}
 		if s.Trendline != "" {
-			fmt.Printf("%s%s\n", padLabel("Trendline:", labelWidth), s.Trendline)
+			r.row("Trendline:", s.Trendline)
+		}
+		if s.EMATrendline != "" {
+			label := fmt.Sprintf("EMA (α=%s):", formatFloat(s.EMAAlpha))
+			r.row(label, s.EMATrendline)
+		}
+		if s.CDFSparkline != "" {
+			r.row("CDF:", s.CDFSparkline)
 		}
 	}
 	if s.TrimDatasetPct > 0 {
-		fmt.Println("\n* computed on trimmed dataset; tail-sensitive statistics may differ from full data")
+		r.text("\n* computed on trimmed dataset; tail-sensitive statistics may differ from full data")
 	}
+	return r.render(labelWidth, opts.Align)
 }
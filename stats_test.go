@@ -1,11 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math"
+	"math/rand/v2"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 const epsilon = 1e-4
@@ -37,7 +48,7 @@ var testData = []float64{
 }
 
 func TestComputeStats(t *testing.T) {
-	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -90,14 +101,14 @@ func TestComputeStats(t *testing.T) {
 }
 
 func TestComputeStatsEmptyInput(t *testing.T) {
-	_, err := computeStats([]float64{}, nil, 1.5, 16, 0, 0, 0)
+	_, err := computeStats([]float64{}, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err == nil {
 		t.Error("expected error for empty input, got nil")
 	}
 }
 
 func TestComputeStatsSingleValue(t *testing.T) {
-	stats, err := computeStats([]float64{42.5}, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats([]float64{42.5}, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -126,7 +137,7 @@ func TestComputeStatsSingleValue(t *testing.T) {
 func TestComputeStatsMultipleMode(t *testing.T) {
 	// 5 and 10 both appear twice
 	data := []float64{5, 5, 10, 10, 15}
-	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -140,7 +151,7 @@ func TestComputeStatsMultipleMode(t *testing.T) {
 func TestComputeStatsNoMode(t *testing.T) {
 	// All values unique - no mode
 	data := []float64{1, 2, 3, 4, 5}
-	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -340,7 +351,7 @@ func TestComputeStatsCustomIQRMultiplier(t *testing.T) {
 	// lowerBound = 27.5 - 3.0*45.125 = -108.875
 	// upperBound = 72.625 + 3.0*45.125 = 208.0
 	// 150 < 208.0, so no outliers
-	stats, err := computeStats(testData, nil, 3.0, 16, 0, 0, 0)
+	stats, err := computeStats(testData, nil, 3.0, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -352,7 +363,7 @@ func TestComputeStatsCustomIQRMultiplier(t *testing.T) {
 	// lowerBound = 27.5 - 1.0*45.125 = -17.625
 	// upperBound = 72.625 + 1.0*45.125 = 117.75
 	// 150 > 117.75, so 150 is an outlier (same as default for this dataset)
-	stats, err = computeStats(testData, nil, 1.0, 16, 0, 0, 0)
+	stats, err = computeStats(testData, nil, 1.0, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -362,7 +373,7 @@ func TestComputeStatsCustomIQRMultiplier(t *testing.T) {
 }
 
 func TestCVForTestData(t *testing.T) {
-	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -398,7 +409,7 @@ func TestInterpretCV(t *testing.T) {
 
 func TestCVWithNegativeData(t *testing.T) {
 	data := []float64{-10, -5, 0, 5, 10, 20, 30}
-	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -409,7 +420,7 @@ func TestCVWithNegativeData(t *testing.T) {
 
 func TestCVWithMeanNearZero(t *testing.T) {
 	data := []float64{-1, 0, 1}
-	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -419,7 +430,7 @@ func TestCVWithMeanNearZero(t *testing.T) {
 }
 
 func TestCVSingleValue(t *testing.T) {
-	stats, err := computeStats([]float64{42.5}, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats([]float64{42.5}, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -447,7 +458,7 @@ func TestGenerateHistogram(t *testing.T) {
 	sorted := make([]float64, len(testData))
 	copy(sorted, testData)
 	sort.Float64s(sorted)
-	result := generateHistogram(sorted, 16)
+	result := generateHistogram(sorted, 16, defaultRamp)
 	if len([]rune(result)) != 16 {
 		t.Errorf("expected 16 runes, got %d", len([]rune(result)))
 	}
@@ -464,7 +475,7 @@ func TestGenerateHistogramUniform(t *testing.T) {
 	for i := range data {
 		data[i] = float64(i + 1)
 	}
-	result := generateHistogram(data, 16)
+	result := generateHistogram(data, 16, defaultRamp)
 	expected := "████████████████"
 	if result != expected {
 		t.Errorf("expected all full blocks, got %q", result)
@@ -472,14 +483,14 @@ func TestGenerateHistogramUniform(t *testing.T) {
 }
 
 func TestGenerateHistogramSingleValue(t *testing.T) {
-	result := generateHistogram([]float64{42}, 16)
+	result := generateHistogram([]float64{42}, 16, defaultRamp)
 	if result != "" {
 		t.Errorf("expected empty string for single value, got %q", result)
 	}
 }
 
 func TestGenerateHistogramAllIdentical(t *testing.T) {
-	result := generateHistogram([]float64{5, 5, 5, 5}, 16)
+	result := generateHistogram([]float64{5, 5, 5, 5}, 16, defaultRamp)
 	if result != "" {
 		t.Errorf("expected empty string for identical values, got %q", result)
 	}
@@ -489,14 +500,14 @@ func TestGenerateHistogramCustomBins(t *testing.T) {
 	sorted := make([]float64, len(testData))
 	copy(sorted, testData)
 	sort.Float64s(sorted)
-	result := generateHistogram(sorted, 8)
+	result := generateHistogram(sorted, 8, defaultRamp)
 	if len([]rune(result)) != 8 {
 		t.Errorf("expected 8 runes, got %d", len([]rune(result)))
 	}
 }
 
 func TestGenerateTrendline(t *testing.T) {
-	result := generateTrendline(testData, 16)
+	result := generateTrendline(testData, 16, defaultRamp)
 	if len([]rune(result)) != 16 {
 		t.Errorf("expected 16 runes, got %d", len([]rune(result)))
 	}
@@ -511,7 +522,7 @@ func TestGenerateTrendline(t *testing.T) {
 func TestGenerateTrendlinePreservesOrder(t *testing.T) {
 	// Ascending input should produce ascending blocks
 	data := []float64{1, 2, 3, 4, 5, 6, 7, 8}
-	result := generateTrendline(data, 8)
+	result := generateTrendline(data, 8, defaultRamp)
 	runes := []rune(result)
 	for i := 1; i < len(runes); i++ {
 		if runes[i] < runes[i-1] {
@@ -521,21 +532,21 @@ func TestGenerateTrendlinePreservesOrder(t *testing.T) {
 }
 
 func TestGenerateTrendlineSingleValue(t *testing.T) {
-	result := generateTrendline([]float64{42}, 16)
+	result := generateTrendline([]float64{42}, 16, defaultRamp)
 	if result != "" {
 		t.Errorf("expected empty string for single value, got %q", result)
 	}
 }
 
 func TestGenerateTrendlineAllIdentical(t *testing.T) {
-	result := generateTrendline([]float64{5, 5, 5, 5}, 16)
+	result := generateTrendline([]float64{5, 5, 5, 5}, 16, defaultRamp)
 	if result != "" {
 		t.Errorf("expected empty string for identical values, got %q", result)
 	}
 }
 
 func TestGenerateTrendlineCustomBins(t *testing.T) {
-	result := generateTrendline(testData, 8)
+	result := generateTrendline(testData, 8, defaultRamp)
 	if len([]rune(result)) != 8 {
 		t.Errorf("expected 8 runes, got %d", len([]rune(result)))
 	}
@@ -544,7 +555,7 @@ func TestGenerateTrendlineCustomBins(t *testing.T) {
 func TestZScoreOutliers(t *testing.T) {
 	// With z=2.0: 150 has Z=(150-51.7258)/33.5751=2.926 > 2.0, so flagged
 	t.Run("Threshold2.0", func(t *testing.T) {
-		stats, err := computeStats(testData, nil, 1.5, 16, 2.0, 0, 0)
+		stats, err := computeStats(testData, nil, 1.5, 16, 2.0, 0, StatsOptions{})
 		if err != nil {
 			t.Fatalf("computeStats returned error: %v", err)
 		}
@@ -559,7 +570,7 @@ func TestZScoreOutliers(t *testing.T) {
 
 	// With z=3.0: 150 has Z=2.926 < 3.0, so no outliers
 	t.Run("Threshold3.0", func(t *testing.T) {
-		stats, err := computeStats(testData, nil, 1.5, 16, 3.0, 0, 0)
+		stats, err := computeStats(testData, nil, 1.5, 16, 3.0, 0, StatsOptions{})
 		if err != nil {
 			t.Fatalf("computeStats returned error: %v", err)
 		}
@@ -570,7 +581,7 @@ func TestZScoreOutliers(t *testing.T) {
 }
 
 func TestZScoreDisabled(t *testing.T) {
-	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -583,7 +594,7 @@ func TestZScoreDisabled(t *testing.T) {
 }
 
 func TestZScoreZeroStdDev(t *testing.T) {
-	stats, err := computeStats([]float64{5, 5, 5}, nil, 1.5, 16, 2.0, 0, 0)
+	stats, err := computeStats([]float64{5, 5, 5}, nil, 1.5, 16, 2.0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -605,7 +616,7 @@ func TestApplyLogTransformPositiveValues(t *testing.T) {
 	}
 
 	// Verify stats on transformed data
-	stats, err := computeStats(result, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(result, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -638,7 +649,7 @@ func TestTrimmedMean(t *testing.T) {
 	// testData has 31 values, trim=10%
 	// trimCount = floor(31 * 10 / 100) = 3, remaining = 25
 	// sorted[3:28] sum = 1242.75, mean = 49.71
-	stats, err := computeStats(testData, nil, 1.5, 16, 0, 10, 0)
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 10, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -651,7 +662,7 @@ func TestTrimmedMean(t *testing.T) {
 }
 
 func TestTrimmedMeanDisabled(t *testing.T) {
-	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -665,7 +676,7 @@ func TestTrimmedMeanDisabled(t *testing.T) {
 
 func TestTrimmedMeanDatasetTooSmall(t *testing.T) {
 	// 4 values with trim=50%: trimCount = floor(4 * 50/100) = 2, remaining = 0 → error
-	_, err := computeStats([]float64{1, 2, 3, 4}, nil, 1.5, 16, 0, 50, 0)
+	_, err := computeStats([]float64{1, 2, 3, 4}, nil, 1.5, 16, 0, 50, StatsOptions{})
 	if err == nil {
 		t.Error("expected error for dataset too small to trim, got nil")
 	}
@@ -675,7 +686,7 @@ func TestTrimmedMeanSmallTrim(t *testing.T) {
 	// 5 values with trim=5%: trimCount = floor(5 * 5/100) = floor(0.25) = 0
 	// No trimming occurs, result equals regular mean
 	data := []float64{1, 2, 3, 4, 5}
-	stats, err := computeStats(data, nil, 1.5, 16, 0, 5, 0)
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 5, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -691,7 +702,7 @@ func TestTrimDataset(t *testing.T) {
 	sort.Float64s(sorted)
 	trimmed := sorted[3 : len(sorted)-3] // 25 values
 
-	stats, err := computeStats(trimmed, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(trimmed, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -700,7 +711,7 @@ func TestTrimDataset(t *testing.T) {
 	}
 
 	// Mean of trimmed data should differ from full data mean
-	fullStats, err := computeStats(testData, nil, 1.5, 16, 0, 0, 0)
+	fullStats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -779,7 +790,7 @@ func TestCalculateEMA(t *testing.T) {
 
 func TestEMAViaComputeStats(t *testing.T) {
 	data := []float64{1, 2, 3, 4, 5}
-	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, 3)
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{EMASpan: 3})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -792,7 +803,7 @@ func TestEMAViaComputeStats(t *testing.T) {
 }
 
 func TestEMADisabled(t *testing.T) {
-	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, 0)
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
 	if err != nil {
 		t.Fatalf("computeStats returned error: %v", err)
 	}
@@ -803,3 +814,3142 @@ func TestEMADisabled(t *testing.T) {
 		t.Errorf("EMA: got %v, expected 0", stats.EMA)
 	}
 }
+
+func TestCalculateMAD(t *testing.T) {
+	// testData median = 50; absolute deviations sorted have median 25
+	got := calculateMAD(testData, 50)
+	if !floatEquals(got, 25) {
+		t.Errorf("calculateMAD: got %v, expected 25", got)
+	}
+}
+
+func TestScaledMAD(t *testing.T) {
+	// {1,2,3,4,5}: median=3, |deviations|={2,1,0,1,2}, MAD=1, scaled=1.4826
+	stats, err := computeStats([]float64{1, 2, 3, 4, 5}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if !floatEquals(stats.MAD, 1) {
+		t.Errorf("MAD: got %v, expected 1", stats.MAD)
+	}
+	if !floatEquals(stats.ScaledMAD, 1.4826) {
+		t.Errorf("ScaledMAD: got %v, expected 1.4826", stats.ScaledMAD)
+	}
+}
+
+func TestOutlierClassification(t *testing.T) {
+	// 1..10 plus 20 and 40: Q1=3.75, Q3=9.25, IQR=5.5
+	// Inner fence upper = 9.25 + 1.5*5.5 = 17.5; outer fence upper = 9.25 + 3*5.5 = 25.75
+	// 20 is mild (between fences), 40 is extreme (beyond outer fence)
+	data := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 20, 40}
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if !floatSliceEquals(stats.Outliers, []float64{20, 40}) {
+		t.Errorf("Outliers: got %v, expected [20 40]", stats.Outliers)
+	}
+	if !floatSliceEquals(stats.MildOutliers, []float64{20}) {
+		t.Errorf("MildOutliers: got %v, expected [20]", stats.MildOutliers)
+	}
+	if !floatSliceEquals(stats.ExtremeOutliers, []float64{40}) {
+		t.Errorf("ExtremeOutliers: got %v, expected [40]", stats.ExtremeOutliers)
+	}
+}
+
+func TestExplainStats(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	explanation := explainStats(stats)
+	for _, want := range []string{"right skewed", "high variability", "150"} {
+		if !strings.Contains(explanation, want) {
+			t.Errorf("explanation missing %q: %s", want, explanation)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("the quick brown fox jumps over the lazy dog", 10)
+	for _, line := range strings.Split(got, "\n") {
+		if len(line) > 10 {
+			t.Errorf("line %q exceeds width 10", line)
+		}
+	}
+	if strings.Join(strings.Fields(got), " ") != "the quick brown fox jumps over the lazy dog" {
+		t.Errorf("wrapText lost words: %q", got)
+	}
+}
+
+func TestApplyShiftedLogTransform(t *testing.T) {
+	// {0,1,2}: min=0, shift=1 → ln(1)=0, ln(2)=0.693147, ln(3)=1.098612
+	result, shift := applyShiftedLogTransform([]float64{0, 1, 2})
+	if !floatEquals(shift, 1) {
+		t.Errorf("shift: got %v, expected 1", shift)
+	}
+	expected := []float64{0, 0.693147, 1.098612}
+	if !floatSliceEquals(result, expected) {
+		t.Errorf("applyShiftedLogTransform: got %v, expected %v", result, expected)
+	}
+	for i, v := range result {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			t.Errorf("value %d is not finite: %v", i, v)
+		}
+		if i > 0 && v <= result[i-1] {
+			t.Errorf("expected increasing values, got %v", result)
+		}
+	}
+}
+
+func TestApplyShiftedLogTransformNegative(t *testing.T) {
+	// {-5,0,5}: min=-5, shift=6 → ln(1), ln(6), ln(11)
+	result, shift := applyShiftedLogTransform([]float64{-5, 0, 5})
+	if !floatEquals(shift, 6) {
+		t.Errorf("shift: got %v, expected 6", shift)
+	}
+	expected := []float64{0, 1.791759, 2.397895}
+	if !floatSliceEquals(result, expected) {
+		t.Errorf("applyShiftedLogTransform: got %v, expected %v", result, expected)
+	}
+}
+
+func TestTrimmedRange(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{TrimRangePct: 5})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+	expected := calculatePercentile(sorted, 0.95) - calculatePercentile(sorted, 0.05)
+	if !floatEquals(stats.TrimmedRange, expected) {
+		t.Errorf("TrimmedRange: got %v, expected %v", stats.TrimmedRange, expected)
+	}
+	if !floatEquals(stats.TrimmedRangePct, 5) {
+		t.Errorf("TrimmedRangePct: got %v, expected 5", stats.TrimmedRangePct)
+	}
+}
+
+func TestTrimmedRangeDisabled(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if stats.TrimmedRangePct != 0 || stats.TrimmedRange != 0 {
+		t.Errorf("expected trimmed range disabled, got %v (%v%%)", stats.TrimmedRange, stats.TrimmedRangePct)
+	}
+}
+
+func TestLowerTailPercentiles(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+
+	tests := []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		{"P1", stats.P1, calculatePercentile(sorted, 0.01)},
+		{"P5", stats.P5, calculatePercentile(sorted, 0.05)},
+		{"P10", stats.P10, calculatePercentile(sorted, 0.10)},
+	}
+	for _, tc := range tests {
+		if !floatEquals(tc.got, tc.expected) {
+			t.Errorf("%s: got %v, expected %v", tc.name, tc.got, tc.expected)
+		}
+	}
+	// P5: rank = 0.05*30 = 1.5, between sorted[1]=5 and sorted[2]=7.75
+	if !floatEquals(stats.P5, 6.375) {
+		t.Errorf("P5: got %v, expected 6.375", stats.P5)
+	}
+}
+
+func TestFormatLatencyTable(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	table := formatLatencyTable(stats, testData, calculatePercentile)
+	values := make(map[string]string)
+	valueEnd := -1
+	for _, line := range strings.Split(strings.TrimSpace(table), "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			t.Fatalf("unexpected table line: %q", line)
+		}
+		values[fields[0]] = fields[1]
+		if valueEnd == -1 {
+			valueEnd = len(line)
+		} else if len(line) != valueEnd {
+			t.Errorf("values not aligned: %q", line)
+		}
+	}
+	for _, label := range []string{"p50:", "p75:", "p90:", "p95:", "p99:", "p99.9:"} {
+		if _, ok := values[label]; !ok {
+			t.Errorf("latency table missing %s: %s", label, table)
+		}
+	}
+	if values["p50:"] != formatFloat(stats.Median) {
+		t.Errorf("p50: got %s, expected median %s", values["p50:"], formatFloat(stats.Median))
+	}
+}
+
+func TestComputeStatsPresorted(t *testing.T) {
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+
+	presortedStats, err := computeStats(sorted, nil, 1.5, 16, 0, 0, StatsOptions{Presorted: true})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		{"Mean", presortedStats.Mean, stats.Mean},
+		{"Median", presortedStats.Median, stats.Median},
+		{"Q1", presortedStats.Q1, stats.Q1},
+		{"Q3", presortedStats.Q3, stats.Q3},
+		{"P99", presortedStats.P99, stats.P99},
+		{"StdDev", presortedStats.StdDev, stats.StdDev},
+	}
+	for _, tc := range tests {
+		if !floatEquals(tc.got, tc.expected) {
+			t.Errorf("%s: got %v, expected %v", tc.name, tc.got, tc.expected)
+		}
+	}
+}
+
+func TestComputeStatsPresortedUnsortedInput(t *testing.T) {
+	_, err := computeStats([]float64{1, 3, 2}, nil, 1.5, 16, 0, 0, StatsOptions{Presorted: true})
+	if err == nil {
+		t.Error("expected error for unsorted input with presorted=true, got nil")
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if len(stats.Duplicates) != 1 {
+		t.Fatalf("Duplicates: got %v, expected only 50", stats.Duplicates)
+	}
+	if !floatEquals(stats.Duplicates[0].Value, 50) || stats.Duplicates[0].Count != 4 {
+		t.Errorf("Duplicates[0]: got %v x%d, expected 50 x4", stats.Duplicates[0].Value, stats.Duplicates[0].Count)
+	}
+	if !strings.Contains(formatDuplicates(stats.Duplicates), "50 x4") {
+		t.Errorf("formatDuplicates: got %q, expected it to list 50 x4", formatDuplicates(stats.Duplicates))
+	}
+	// The report must not alter any statistic
+	if !floatEquals(stats.Mean, 51.7258) {
+		t.Errorf("Mean: got %v, expected 51.7258", stats.Mean)
+	}
+}
+
+func TestDuplicatesOrdering(t *testing.T) {
+	stats, err := computeStats([]float64{1, 2, 2, 3, 3, 3, 4, 4}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	expected := []ValueCount{{3, 3}, {2, 2}, {4, 2}}
+	if len(stats.Duplicates) != len(expected) {
+		t.Fatalf("Duplicates: got %v, expected %v", stats.Duplicates, expected)
+	}
+	for i, d := range expected {
+		if stats.Duplicates[i] != d {
+			t.Errorf("Duplicates[%d]: got %v, expected %v", i, stats.Duplicates[i], d)
+		}
+	}
+}
+
+func TestGenerateHistogramASCIIRamp(t *testing.T) {
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+	ascii := histogramRamps["ascii"]
+	for name, result := range map[string]string{
+		"histogram": generateHistogram(sorted, 16, ascii),
+		"trendline": generateTrendline(testData, 16, ascii),
+	} {
+		if len([]rune(result)) != 16 {
+			t.Errorf("%s: expected 16 runes, got %d", name, len([]rune(result)))
+		}
+		for _, r := range result {
+			if !strings.ContainsRune(".:-=+*#%@", r) {
+				t.Errorf("%s: invalid ascii character: %c", name, r)
+			}
+		}
+	}
+}
+
+func TestGenerateHistogramRampTopLevel(t *testing.T) {
+	// Uniform data fills every bin equally, so every bin uses the top character of the ramp
+	data := make([]float64, 16)
+	for i := range data {
+		data[i] = float64(i + 1)
+	}
+	result := generateHistogram(data, 16, histogramRamps["ascii"])
+	if result != strings.Repeat("@", 16) {
+		t.Errorf("expected all top-level characters, got %q", result)
+	}
+}
+
+func TestCalculateRanks(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []float64
+		expected []float64
+	}{
+		{"Ties", []float64{10, 20, 20, 30}, []float64{1, 2.5, 2.5, 4}},
+		{"UnsortedInput", []float64{30, 10, 20}, []float64{3, 1, 2}},
+		{"AllTied", []float64{5, 5, 5}, []float64{2, 2, 2}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := calculateRanks(tc.data)
+			if !floatSliceEquals(got, tc.expected) {
+				t.Errorf("calculateRanks(%v): got %v, expected %v", tc.data, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestComputeExtremes(t *testing.T) {
+	lines := make([]string, len(testData))
+	for i, v := range testData {
+		lines[i] = formatFloat(v)
+	}
+	extremes, err := computeExtremes(strings.NewReader(strings.Join(lines, "\n")+"\n"), nil)
+	if err != nil {
+		t.Fatalf("computeExtremes returned error: %v", err)
+	}
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if extremes.Count != stats.Count {
+		t.Errorf("Count: got %d, expected %d", extremes.Count, stats.Count)
+	}
+	if !floatEquals(extremes.Min, stats.Min) {
+		t.Errorf("Min: got %v, expected %v", extremes.Min, stats.Min)
+	}
+	if !floatEquals(extremes.Max, stats.Max) {
+		t.Errorf("Max: got %v, expected %v", extremes.Max, stats.Max)
+	}
+}
+
+func TestComputeExtremesEmpty(t *testing.T) {
+	_, err := computeExtremes(strings.NewReader("\ninvalid\n"), nil)
+	if err == nil {
+		t.Error("expected error for input with no valid numbers, got nil")
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, data := range [][]float64{testData, {1, 2, 3}} {
+		stats, err := computeStats(data, []float64{90}, 1.5, 16, 0, 0, StatsOptions{})
+		if err != nil {
+			t.Fatalf("computeStats returned error: %v", err)
+		}
+		if err := writeJSONL(&buf, stats, "", start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("writeJSONL returned error: %v", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	var prev time.Time
+	lines := 0
+	for scanner.Scan() {
+		var record struct {
+			Timestamp         time.Time          `json:"timestamp"`
+			Count             int                `json:"Count"`
+			Mean              float64            `json:"Mean"`
+			CustomPercentiles map[string]float64 `json:"CustomPercentiles"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", lines+1, err)
+		}
+		// Display toggles belong to ReportOptions, not to the recorded statistics
+		for _, toggle := range []string{"ShowMAD", "ShowMissing", "NoHeader", "Align", "Precision", "HistLog"} {
+			if bytes.Contains(scanner.Bytes(), []byte(`"`+toggle+`"`)) {
+				t.Errorf("line %d contains display toggle %s: %s", lines+1, toggle, scanner.Bytes())
+			}
+		}
+		if lines > 0 && !record.Timestamp.After(prev) {
+			t.Errorf("timestamp did not increase: %v then %v", prev, record.Timestamp)
+		}
+		if _, ok := record.CustomPercentiles["90"]; !ok {
+			t.Errorf("line %d missing custom percentile 90: %v", lines+1, record.CustomPercentiles)
+		}
+		if lines == 0 && (record.Count != 31 || !floatEquals(record.Mean, 51.7258)) {
+			t.Errorf("first record: got Count=%d Mean=%v, expected 31 and 51.7258", record.Count, record.Mean)
+		}
+		prev = record.Timestamp
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("expected 2 JSON lines, got %d", lines)
+	}
+
+	// With -per-file each input is an update with its own record, stamped in order
+	dir := t.TempDir()
+	names := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), filepath.Join(dir, "c.txt")}
+	for i, name := range names {
+		if err := os.WriteFile(name, []byte(fmt.Sprintf("%d\n%d\n", i, i+10)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", append([]string{"run", "stats.go", "-per-file", "-jsonl"}, names...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, output)
+	}
+	records := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(records) != len(names) {
+		t.Fatalf("expected %d JSON lines, got %d: %s", len(names), len(records), output)
+	}
+	prev = time.Time{}
+	for i, line := range records {
+		var record struct {
+			Timestamp time.Time `json:"timestamp"`
+			Source    string    `json:"source"`
+			Mean      float64   `json:"Mean"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", i+1, err)
+		}
+		if record.Source != names[i] || record.Mean != float64(i)+5 {
+			t.Errorf("line %d: got source %q, mean %v; expected %q, %v", i+1, record.Source, record.Mean, names[i], float64(i)+5)
+		}
+		if i > 0 && !record.Timestamp.After(prev) {
+			t.Errorf("timestamp did not increase: %v then %v", prev, record.Timestamp)
+		}
+		prev = record.Timestamp
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"Fields", "{{.Count}}:{{.Mean}}", "31:51.725806451612904"},
+		{"Format", "{{format .Mean}},{{format .Median}}", "51.7258,50"},
+		{"FormatSlice", "{{formatSlice .Outliers}}", "[150]"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := renderTemplate(tc.text, stats)
+			if err != nil {
+				t.Fatalf("renderTemplate returned error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("renderTemplate(%q): got %q, expected %q", tc.text, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateInvalid(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	t.Run("ParseError", func(t *testing.T) {
+		_, err := renderTemplate("{{.Mean", stats)
+		if err == nil || !strings.Contains(err.Error(), "invalid template") {
+			t.Errorf("expected parse error, got %v", err)
+		}
+	})
+	t.Run("UnknownField", func(t *testing.T) {
+		_, err := renderTemplate("{{.NoSuchField}}", stats)
+		if err == nil {
+			t.Error("expected error for unknown field, got nil")
+		}
+	})
+}
+
+func TestMergeStats(t *testing.T) {
+	half := len(testData) / 2
+	a, err := computeStats(testData[:half], nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	b, err := computeStats(testData[half:], nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	whole, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+
+	merged := MergeStats(a, b)
+	if merged.Count != whole.Count {
+		t.Errorf("Count: got %d, expected %d", merged.Count, whole.Count)
+	}
+	tests := []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		{"Sum", merged.Sum, whole.Sum},
+		{"Mean", merged.Mean, whole.Mean},
+		{"Variance", merged.Variance, whole.Variance},
+		{"StdDev", merged.StdDev, whole.StdDev},
+		{"CV", merged.CV, whole.CV},
+		{"Min", merged.Min, whole.Min},
+		{"Max", merged.Max, whole.Max},
+	}
+	for _, tc := range tests {
+		if !floatEquals(tc.got, tc.expected) {
+			t.Errorf("%s: got %v, expected %v", tc.name, tc.got, tc.expected)
+		}
+	}
+	if !merged.Merged {
+		t.Error("Merged: got false, expected true")
+	}
+}
+
+func TestMergeStatsEmptySide(t *testing.T) {
+	a, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if got := MergeStats(a, nil); got != a {
+		t.Error("MergeStats(a, nil) should return a unchanged")
+	}
+	if got := MergeStats(&Stats{}, a); got != a {
+		t.Error("MergeStats(empty, a) should return a unchanged")
+	}
+}
+
+func TestAllowEmpty(t *testing.T) {
+	t.Run("WithoutFlag", func(t *testing.T) {
+		cmd := exec.Command("go", "run", "stats.go", "-")
+		cmd.Stdin = strings.NewReader("\ninvalid\n")
+		output, err := cmd.CombinedOutput()
+		if err == nil {
+			t.Fatalf("expected non-zero exit for empty input, got success: %s", output)
+		}
+		if !strings.Contains(string(output), "no valid numbers") {
+			t.Errorf("expected 'no valid numbers' error, got: %s", output)
+		}
+	})
+
+	t.Run("WithFlag", func(t *testing.T) {
+		cmd := exec.Command("go", "run", "stats.go", "-allow-empty", "-")
+		cmd.Stdin = strings.NewReader("\ninvalid\n")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("expected exit code 0 with -allow-empty, got %v: %s", err, output)
+		}
+		if !strings.Contains(string(output), "No data") {
+			t.Errorf("expected 'No data' message, got: %s", output)
+		}
+	})
+}
+
+func TestRMS(t *testing.T) {
+	// {3,4}: sqrt((9+16)/2) = sqrt(12.5) = 3.5355
+	stats, err := computeStats([]float64{3, 4}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if !floatEquals(stats.RMS, 3.5355) {
+		t.Errorf("RMS: got %v, expected 3.5355", stats.RMS)
+	}
+	// RMS measures magnitude from zero, so it differs from StdDev (0.7071)
+	if floatEquals(stats.RMS, stats.StdDev) {
+		t.Errorf("RMS (%v) should differ from StdDev (%v)", stats.RMS, stats.StdDev)
+	}
+}
+
+func TestComputeChunks(t *testing.T) {
+	data := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	chunks := computeChunks(data, 5)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	expected := []ChunkSummary{
+		{Start: 1, End: 5, Mean: 3, Min: 1, Max: 5},
+		{Start: 6, End: 10, Mean: 8, Min: 6, Max: 10},
+	}
+	for i, e := range expected {
+		if chunks[i] != e {
+			t.Errorf("chunk %d: got %+v, expected %+v", i+1, chunks[i], e)
+		}
+	}
+}
+
+func TestComputeChunksPartial(t *testing.T) {
+	// The last partial chunk is included
+	chunks := computeChunks([]float64{1, 2, 3, 4, 5, 6, 7}, 3)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	last := chunks[2]
+	if last.Start != 7 || last.End != 7 || !floatEquals(last.Mean, 7) {
+		t.Errorf("last chunk: got %+v, expected a single value 7", last)
+	}
+}
+
+func TestResampleSeries(t *testing.T) {
+	ramp := make([]float64, 100)
+	for i := range ramp {
+		ramp[i] = float64(i + 1)
+	}
+	got := resampleSeries(ramp, 10)
+	if len(got) != 10 {
+		t.Fatalf("expected 10 buckets, got %d", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Errorf("expected increasing averages, got %v", got)
+			break
+		}
+	}
+	// First bucket averages 1..10, last averages 91..100
+	if !floatEquals(got[0], 5.5) || !floatEquals(got[9], 95.5) {
+		t.Errorf("bucket averages: got first=%v last=%v, expected 5.5 and 95.5", got[0], got[9])
+	}
+}
+
+func TestResampleSeriesShortInput(t *testing.T) {
+	data := []float64{3, 1, 2}
+	got := resampleSeries(data, 10)
+	if !floatSliceEquals(got, data) {
+		t.Errorf("expected input unchanged when shorter than bucket count, got %v", got)
+	}
+}
+
+func TestIsNearConstant(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []float64
+		expected bool
+	}{
+		{"AllEqual", []float64{5, 5, 5}, true},
+		{"TinyRelativeSpread", []float64{1000, 1000.0001, 999.9999}, true},
+		{"TestData", testData, false},
+		{"ZeroMean", []float64{-1, 0, 1}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stats, err := computeStats(tc.data, nil, 1.5, 16, 0, 0, StatsOptions{})
+			if err != nil {
+				t.Fatalf("computeStats returned error: %v", err)
+			}
+			if got := isNearConstant(stats, 0.001); got != tc.expected {
+				t.Errorf("isNearConstant(%v): got %v, expected %v", tc.data, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestOutlierExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []float64
+		zScore   float64
+		iqrK     float64
+		exitCode int
+	}{
+		{"TestDataHasOutlier", testData, 0, 1.5, 2},
+		{"CleanData", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 0, 1.5, 0},
+		// With k=3.0 there are no IQR outliers, but Z>2 still flags 150
+		{"ZScoreOnly", testData, 2.0, 3.0, 2},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stats, err := computeStats(tc.data, nil, tc.iqrK, 16, tc.zScore, 0, StatsOptions{})
+			if err != nil {
+				t.Fatalf("computeStats returned error: %v", err)
+			}
+			if got := outlierExitCode(stats); got != tc.exitCode {
+				t.Errorf("outlierExitCode: got %d, expected %d", got, tc.exitCode)
+			}
+		})
+	}
+}
+
+func TestReadNumbersWithMissing(t *testing.T) {
+	input := "10\nabc\n20\n1.2.3\n30\n"
+	numbers, missing, err := readNumbersWithMissing(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readNumbersWithMissing returned error: %v", err)
+	}
+	if missing != 2 {
+		t.Errorf("MissingCount: got %d, expected 2", missing)
+	}
+	if !floatSliceEquals(numbers, []float64{10, 20, 30}) {
+		t.Errorf("numbers: got %v, expected [10 20 30]", numbers)
+	}
+}
+
+func TestReadNumbersWithMissingBlankLines(t *testing.T) {
+	_, missing, err := readNumbersWithMissing(strings.NewReader("1\n\n2\n   \n3\n"))
+	if err != nil {
+		t.Fatalf("readNumbersWithMissing returned error: %v", err)
+	}
+	if missing != 2 {
+		t.Errorf("MissingCount: got %d, expected 2 blank lines", missing)
+	}
+}
+
+func TestReadNumbersWithMissingNonFinite(t *testing.T) {
+	numbers, missing, err := readNumbersWithMissing(strings.NewReader("1\nNaN\n2\nInf\n-Inf\n+inf\n3\n"))
+	if err != nil {
+		t.Fatalf("readNumbersWithMissing returned error: %v", err)
+	}
+	if missing != 4 {
+		t.Errorf("MissingCount: got %d, expected 4 non-finite lines", missing)
+	}
+	if !floatSliceEquals(numbers, []float64{1, 2, 3}) {
+		t.Errorf("numbers: got %v, expected [1 2 3]", numbers)
+	}
+}
+
+func TestPositiveMeans(t *testing.T) {
+	// {1,2,4}: AM = 7/3 = 2.3333, GM = cbrt(8) = 2, HM = 3/(1+0.5+0.25) = 1.7143
+	stats, err := computeStats([]float64{1, 2, 4}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if !stats.PositiveMeans {
+		t.Fatal("PositiveMeans: got false, expected true")
+	}
+	if !floatEquals(stats.Mean, 2.3333) {
+		t.Errorf("AM: got %v, expected 2.3333", stats.Mean)
+	}
+	if !floatEquals(stats.GeometricMean, 2.0) {
+		t.Errorf("GM: got %v, expected 2.0", stats.GeometricMean)
+	}
+	if !floatEquals(stats.HarmonicMean, 1.7143) {
+		t.Errorf("HM: got %v, expected 1.7143", stats.HarmonicMean)
+	}
+	if !(stats.Mean >= stats.GeometricMean && stats.GeometricMean >= stats.HarmonicMean) {
+		t.Errorf("expected AM >= GM >= HM, got %v, %v, %v", stats.Mean, stats.GeometricMean, stats.HarmonicMean)
+	}
+	if got := meansRelationship(stats.Mean, stats.GeometricMean, stats.HarmonicMean); !strings.HasPrefix(got, "AM > GM > HM") {
+		t.Errorf("meansRelationship: got %q, expected AM > GM > HM", got)
+	}
+}
+
+func TestPositiveMeansEdgeCases(t *testing.T) {
+	t.Run("NonPositive", func(t *testing.T) {
+		if _, _, ok := calculatePositiveMeans([]float64{1, 0, 2}); ok {
+			t.Error("expected ok=false when data contains zero")
+		}
+	})
+	t.Run("AllEqual", func(t *testing.T) {
+		gm, hm, ok := calculatePositiveMeans([]float64{3, 3, 3})
+		if !ok || !floatEquals(gm, 3) || !floatEquals(hm, 3) {
+			t.Errorf("got gm=%v hm=%v ok=%v, expected 3, 3, true", gm, hm, ok)
+		}
+		if got := meansRelationship(3, gm, hm); !strings.HasPrefix(got, "AM = GM = HM") {
+			t.Errorf("meansRelationship: got %q, expected AM = GM = HM", got)
+		}
+	})
+}
+
+func TestFormatSortedValues(t *testing.T) {
+	out := formatSortedValues(testData, 40, -1)
+	var values []float64
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 40 {
+			t.Errorf("line exceeds width 40: %q", line)
+		}
+		for _, field := range strings.Fields(line) {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				t.Fatalf("invalid number %q in output", field)
+			}
+			values = append(values, v)
+		}
+	}
+	if len(values) != len(testData) {
+		t.Errorf("expected %d values, got %d", len(testData), len(values))
+	}
+	if !sort.Float64sAreSorted(values) {
+		t.Errorf("expected non-decreasing values, got %v", values)
+	}
+
+	if got := formatSortedValues([]float64{62.5, 3, 7.75}, 80, 2); got != "3.00 7.75 62.50" {
+		t.Errorf("precision 2: got %q, expected %q", got, "3.00 7.75 62.50")
+	}
+}
+
+func TestMeanSideCounts(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if total := stats.AboveMean + stats.BelowMean + stats.AtMean; total != 31 {
+		t.Errorf("AboveMean+BelowMean+AtMean: got %d, expected 31", total)
+	}
+	// Mean=51.7258: 13 values are above it, 18 below (including the four 50s)
+	if stats.AboveMean != 13 || stats.BelowMean != 18 || stats.AtMean != 0 {
+		t.Errorf("got %d above, %d below, %d at; expected 13, 18, 0", stats.AboveMean, stats.BelowMean, stats.AtMean)
+	}
+
+	stats, err = computeStats([]float64{1, 2, 3}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if stats.AboveMean != 1 || stats.BelowMean != 1 || stats.AtMean != 1 {
+		t.Errorf("{1,2,3}: got %d above, %d below, %d at; expected 1, 1, 1", stats.AboveMean, stats.BelowMean, stats.AtMean)
+	}
+}
+
+func TestPercentileMidpoint(t *testing.T) {
+	data := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p        float64
+		expected float64
+	}{
+		{0.25, 3.5}, // rank 2.25 falls between 3 and 4
+		{0.50, 5.5},
+		{0.75, 7.5},
+		{0, 1},
+		{1, 10},
+	}
+	for _, tt := range tests {
+		got := calculatePercentileMidpoint(data, tt.p)
+		if math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("calculatePercentileMidpoint(%v): got %v, expected %v", tt.p, got, tt.expected)
+		}
+	}
+
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{Percentile: percentileMethods["midpoint"]})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if stats.Q1 != 3.5 {
+		t.Errorf("midpoint Q1: got %v, expected 3.5", stats.Q1)
+	}
+	linear, _ := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if linear.Q1 != 3.25 {
+		t.Errorf("linear Q1: got %v, expected 3.25", linear.Q1)
+	}
+}
+
+func TestComputePerFile(t *testing.T) {
+	inputs := map[string]string{
+		"a.txt": "1\n2\n3\n",
+		"b.txt": "10\nNaN\n20\n\n",
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		text, ok := inputs[name]
+		if !ok {
+			return nil, fmt.Errorf("open %s: no such file", name)
+		}
+		return io.NopCloser(strings.NewReader(text)), nil
+	}
+	compute := func(numbers []float64) (*Stats, error) {
+		return computeStats(numbers, nil, 1.5, 16, 0, 0, StatsOptions{})
+	}
+
+	results, combined, err := computePerFile([]string{"a.txt", "missing.txt", "b.txt"}, open, compute)
+	if err != nil {
+		t.Fatalf("computePerFile returned error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results: got %d, expected 3", len(results))
+	}
+
+	if results[0].Name != "a.txt" || results[0].Err != nil || results[0].Stats.Count != 3 || results[0].Stats.Mean != 2 {
+		t.Errorf("a.txt: got %+v", results[0])
+	}
+	if results[1].Name != "missing.txt" || results[1].Err == nil {
+		t.Errorf("missing.txt: expected an error, got %+v", results[1])
+	}
+	if results[2].Name != "b.txt" || results[2].Err != nil || results[2].Stats.Count != 2 || results[2].Stats.Mean != 15 {
+		t.Errorf("b.txt: got %+v", results[2])
+	}
+
+	// The combined report covers only the inputs that were read successfully
+	if combined.Count != 5 {
+		t.Errorf("combined Count: got %d, expected 5", combined.Count)
+	}
+	if combined.Sum != 36 {
+		t.Errorf("combined Sum: got %v, expected 36", combined.Sum)
+	}
+	if combined.Min != 1 || combined.Max != 20 {
+		t.Errorf("combined Min/Max: got %v/%v, expected 1/20", combined.Min, combined.Max)
+	}
+	if results[0].Stats.MissingCount != 0 || results[2].Stats.MissingCount != 2 || combined.MissingCount != 2 {
+		t.Errorf("MissingCount: got %d, %d, and %d combined; expected 0, 2, and 2", results[0].Stats.MissingCount, results[2].Stats.MissingCount, combined.MissingCount)
+	}
+
+	_, _, err = computePerFile([]string{"missing.txt"}, open, compute)
+	if err == nil {
+		t.Error("expected an error when no input could be read")
+	}
+}
+
+func TestHistogramClipOutliers(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+
+	clipped := withinFences(testData, stats, 1.5)
+	if len(clipped) != len(testData)-1 {
+		t.Fatalf("withinFences: got %d values, expected %d (150 excluded)", len(clipped), len(testData)-1)
+	}
+	if clipped[len(clipped)-1] != 100 {
+		t.Errorf("withinFences max: got %v, expected 100", clipped[len(clipped)-1])
+	}
+
+	// Count bins drawn above the lowest level; 150 crams most values into fewer bins
+	raisedBins := func(hist string) int {
+		n := 0
+		for _, r := range hist {
+			if r != defaultRamp[0] {
+				n++
+			}
+		}
+		return n
+	}
+	full := raisedBins(stats.Histogram)
+	clip := raisedBins(generateHistogram(clipped, 16, defaultRamp))
+	if clip <= full {
+		t.Errorf("clipped histogram raises %d bins, expected more than the full histogram's %d", clip, full)
+	}
+}
+
+func TestCDFSparkline(t *testing.T) {
+	spark := []rune(generateCDFSparkline(testData, 16, defaultRamp))
+	if len(spark) != 16 {
+		t.Fatalf("length: got %d, expected 16", len(spark))
+	}
+	if spark[len(spark)-1] != '█' {
+		t.Errorf("last rune: got %q, expected '█'", spark[len(spark)-1])
+	}
+	level := func(r rune) int {
+		for i, c := range defaultRamp {
+			if c == r {
+				return i
+			}
+		}
+		return -1
+	}
+	for i := 1; i < len(spark); i++ {
+		if level(spark[i]) < level(spark[i-1]) {
+			t.Errorf("sparkline decreases at position %d: %q", i, string(spark))
+			break
+		}
+	}
+
+	if got := generateCDFSparkline([]float64{5, 5, 5}, 16, defaultRamp); got != "" {
+		t.Errorf("constant data: got %q, expected empty", got)
+	}
+}
+
+func TestCalculateTargetError(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []float64
+		target       float64
+		expectedMAPE float64
+		expectedBias float64
+	}{
+		{"symmetric around target", []float64{9, 10, 11}, 10, 20.0 / 3, 0},
+		{"all above target", []float64{11, 12}, 10, 15, 1.5},
+		{"negative target", []float64{-9, -11}, -10, 10, 0},
+	}
+	for _, tt := range tests {
+		mape, bias := calculateTargetError(tt.data, tt.target)
+		if math.Abs(mape-tt.expectedMAPE) > 1e-9 {
+			t.Errorf("%s MAPE: got %v, expected %v", tt.name, mape, tt.expectedMAPE)
+		}
+		if math.Abs(bias-tt.expectedBias) > 1e-9 {
+			t.Errorf("%s Bias: got %v, expected %v", tt.name, bias, tt.expectedBias)
+		}
+	}
+}
+
+func TestFormatBaselineDiff(t *testing.T) {
+	baseline, err := computeStats([]float64{1, 2, 3}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	current, err := computeStats([]float64{2, 3, 4}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+
+	rows := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(formatBaselineDiff(baseline, current)), "\n") {
+		fields := strings.Fields(line)
+		rows[fields[0]] = fields[1:]
+	}
+
+	tests := []struct {
+		label    string
+		expected []string
+	}{
+		{"Mean", []string{"2", "3", "+1", "+50%"}},
+		{"Median", []string{"2", "3", "+1", "+50%"}},
+		{"Min", []string{"1", "2", "+1", "+100%"}},
+		{"Count", []string{"3", "3", "0", "0%"}},
+		{"IQR", []string{"1", "1", "0", "0%"}},
+	}
+	for _, tt := range tests {
+		got := rows[tt.label]
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%s row: got %v, expected %v", tt.label, got, tt.expected)
+		}
+	}
+}
+
+func TestExcludeZeros(t *testing.T) {
+	data := excludeZeros([]float64{0, 1, 0, 3})
+	if len(data) != 2 || data[0] != 1 || data[1] != 3 {
+		t.Fatalf("excludeZeros: got %v, expected [1 3]", data)
+	}
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if stats.Count != 2 {
+		t.Errorf("Count: got %d, expected 2", stats.Count)
+	}
+	if stats.Mean != 2 {
+		t.Errorf("Mean: got %v, expected 2", stats.Mean)
+	}
+	if !stats.PositiveMeans {
+		t.Error("PositiveMeans: expected true once zeros are excluded")
+	}
+
+	if got := excludeZeros([]float64{0, 0}); len(got) != 0 {
+		t.Errorf("all zeros: got %v, expected empty", got)
+	}
+}
+
+func TestNthValue(t *testing.T) {
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+
+	tests := []struct {
+		k        int
+		expected float64
+	}{
+		{1, 3},
+		{-1, 150},
+		{2, 5},
+		{-2, 100},
+		{31, 150},
+		{-31, 3},
+	}
+	for _, tt := range tests {
+		got, err := nthValue(sorted, tt.k)
+		if err != nil {
+			t.Errorf("nthValue(%d) returned error: %v", tt.k, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("nthValue(%d): got %v, expected %v", tt.k, got, tt.expected)
+		}
+	}
+
+	for _, k := range []int{0, 32, -32} {
+		if _, err := nthValue(sorted, k); err == nil {
+			t.Errorf("nthValue(%d): expected an out-of-range error", k)
+		}
+	}
+}
+
+func TestReadKeyedNumbers(t *testing.T) {
+	input := "web 10\ndb 100\nweb 20\n\ndb 300\nweb 30\nweb abc\nbogus\ndb NaN\nweb Inf\n"
+	groups, missing, unkeyed, err := readKeyedNumbers(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readKeyedNumbers returned error: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("groups: got %d, expected 2", len(groups))
+	}
+	// "web abc" and "web Inf" are counted under web, "db NaN" under db, and the blank line and
+	// "bogus" have no key
+	if missing["web"] != 2 || missing["db"] != 1 || unkeyed != 2 {
+		t.Errorf("skipped: got %v and %d without a key, expected web 2, db 1, and 2", missing, unkeyed)
+	}
+
+	tests := []struct {
+		key           string
+		expectedCount int
+		expectedMean  float64
+	}{
+		{"web", 3, 20},
+		{"db", 2, 200},
+	}
+	for _, tt := range tests {
+		stats, err := computeStats(groups[tt.key], nil, 1.5, 16, 0, 0, StatsOptions{})
+		if err != nil {
+			t.Fatalf("computeStats(%s) returned error: %v", tt.key, err)
+		}
+		if stats.Count != tt.expectedCount {
+			t.Errorf("%s Count: got %d, expected %d", tt.key, stats.Count, tt.expectedCount)
+		}
+		if stats.Mean != tt.expectedMean {
+			t.Errorf("%s Mean: got %v, expected %v", tt.key, stats.Mean, tt.expectedMean)
+		}
+	}
+}
+
+func TestCalculateEMASeries(t *testing.T) {
+	series := calculateEMASeries([]float64{0, 0, 10, 10}, 0.5)
+	expected := []float64{0, 0, 5, 7.5}
+	if len(series) != len(expected) {
+		t.Fatalf("length: got %d, expected %d", len(series), len(expected))
+	}
+	for i := range expected {
+		if math.Abs(series[i]-expected[i]) > 1e-9 {
+			t.Errorf("series[%d]: got %v, expected %v", i, series[i], expected[i])
+		}
+	}
+
+	// After the step, the EMA moves toward 10 without overshooting it
+	for i := 3; i < len(series); i++ {
+		if series[i] <= series[i-1] || series[i] > 10 {
+			t.Errorf("series[%d] = %v does not converge toward 10 from %v", i, series[i], series[i-1])
+		}
+	}
+
+	// The final value matches calculateEMA for the equivalent span: alpha = 2/(span+1)
+	long := calculateEMASeries(testData, 2.0/6.0)
+	if math.Abs(long[len(long)-1]-calculateEMA(testData, 5)) > 1e-9 {
+		t.Errorf("final EMA: got %v, expected %v", long[len(long)-1], calculateEMA(testData, 5))
+	}
+}
+
+func TestCalculateAutocorrelation(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []float64
+		lag      int
+		expected float64
+	}{
+		{"periodic at its period", []float64{1, 2, 1, 2, 1, 2}, 2, 2.0 / 3},
+		{"periodic at half its period", []float64{1, 2, 1, 2, 1, 2}, 1, -5.0 / 6},
+		{"linear trend", []float64{1, 2, 3, 4, 5}, 1, 0.4},
+	}
+	for _, tt := range tests {
+		mean := 0.0
+		for _, v := range tt.data {
+			mean += v
+		}
+		mean /= float64(len(tt.data))
+		r, ok := calculateAutocorrelation(tt.data, mean, tt.lag)
+		if !ok {
+			t.Errorf("%s: expected a valid coefficient", tt.name)
+			continue
+		}
+		if math.Abs(r-tt.expected) > 1e-9 {
+			t.Errorf("%s: got %v, expected %v", tt.name, r, tt.expected)
+		}
+		if r < -1 || r > 1 {
+			t.Errorf("%s: %v is outside [-1, 1]", tt.name, r)
+		}
+	}
+
+	if _, ok := calculateAutocorrelation([]float64{4, 4, 4, 4}, 4, 1); ok {
+		t.Error("constant data: expected ok to be false")
+	}
+}
+
+func TestFormatRobustSummary(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 10, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	out := formatRobustSummary(stats)
+
+	for _, label := range []string{"Median (p50):", "MAD:", "MAD (scaled):", "IQR:", "Bowley Skewness:", "Trimmed Mean (10%):"} {
+		if !strings.Contains(out, label) {
+			t.Errorf("robust summary missing %q:\n%s", label, out)
+		}
+	}
+	for _, label := range []string{"Std Deviation", "Variance", "Mean:", "Kurtosis"} {
+		if strings.Contains(out, label) {
+			t.Errorf("robust summary should not contain %q:\n%s", label, out)
+		}
+	}
+
+	// Q1=27.5, Median=50, Q3=72.625: (72.625 + 27.5 - 100) / 45.125
+	skew, ok := bowleySkewness(stats)
+	if !ok || math.Abs(skew-0.125/45.125) > 1e-9 {
+		t.Errorf("bowleySkewness: got %v (ok=%v), expected %v", skew, ok, 0.125/45.125)
+	}
+}
+
+func TestPercentileCI(t *testing.T) {
+	data := make([]float64, 100)
+	for i := range data {
+		data[i] = float64(i + 1)
+	}
+
+	ci, err := percentileCI(data, 0.5, 0.95)
+	if err != nil {
+		t.Fatalf("percentileCI returned error: %v", err)
+	}
+	// Standard tables give the 40th and 61st order statistics for the median of 100 values at 95%
+	if ci.Lower != 40 || ci.Upper != 61 {
+		t.Errorf("ranks: got %d and %d, expected 40 and 61", ci.Lower, ci.Upper)
+	}
+	median := calculatePercentile(data, 0.5)
+	if ci.LowerValue > median || ci.UpperValue < median {
+		t.Errorf("CI [%v, %v] does not bracket the median %v", ci.LowerValue, ci.UpperValue, median)
+	}
+	if ci.Coverage < 0.95 || ci.Coverage > 1 {
+		t.Errorf("Coverage: got %v, expected at least 0.95", ci.Coverage)
+	}
+
+	// Too few values to reach 95% confidence for an extreme percentile
+	if _, err := percentileCI([]float64{1, 2, 3}, 0.99, 0.95); err == nil {
+		t.Error("expected an error for too few values")
+	}
+}
+
+func TestNoHeader(t *testing.T) {
+	input := "1\n2\n3\n4\n5\n6\n"
+	cmd := exec.Command("go", "run", "stats.go", "-no-header", "-")
+	cmd.Stdin = strings.NewReader(input)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, output)
+	}
+	out := string(output)
+
+	for _, banner := range []string{"Descriptive Statistics", "Measures of Central Tendency", "Measures of Spread", "--- Distribution ---"} {
+		if strings.Contains(out, banner) {
+			t.Errorf("expected banner %q to be absent, got:\n%s", banner, out)
+		}
+	}
+	for _, line := range []string{"Count:", "Mean:", "Median (p50):", "Std Deviation:", "Histogram:"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected value line %q, got:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "\n\n") {
+		t.Errorf("expected no blank separator lines, got:\n%s", out)
+	}
+}
+
+func TestFormatReportAlign(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 2, 10, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	out := formatReport(stats, ReportOptions{Align: true}, 19)
+
+	colon := -1
+	numberEnd := -1
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if line == "" || strings.HasPrefix(line, "---") {
+			continue
+		}
+		idx := strings.Index(line, ":")
+		if colon == -1 {
+			colon = idx
+		}
+		if idx != colon {
+			t.Errorf("colon at index %d, expected %d: %q", idx, colon, line)
+		}
+
+		// Numeric values are right-aligned, so they all end in the same column
+		value := line[idx+2:]
+		if number, _, ok := leadingNumber(strings.TrimLeft(value, " ")); ok {
+			end := idx + 2 + strings.Index(value, number) + len(number)
+			if numberEnd == -1 {
+				numberEnd = end
+			}
+			if end != numberEnd {
+				t.Errorf("number ends at index %d, expected %d: %q", end, numberEnd, line)
+			}
+		}
+	}
+	if colon == -1 {
+		t.Fatal("no label lines found")
+	}
+
+	// Without -align the report is unchanged: labels padded to labelWidth
+	if out := formatReport(stats, ReportOptions{}, 19); !strings.Contains(out, "Count:             31\n") {
+		t.Errorf("default layout changed:\n%s", out)
+	}
+}
+
+func TestExtractNumbers(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []float64
+	}{
+		{"latency=42.5ms count=7", []float64{42.5, 7}},
+		{"delta -3 scale 1.5e3", []float64{-3, 1500}},
+		{"no numbers here", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := extractNumbers(tt.line)
+		if len(got) != len(tt.expected) {
+			t.Errorf("extractNumbers(%q): got %v, expected %v", tt.line, got, tt.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("extractNumbers(%q): got %v, expected %v", tt.line, got, tt.expected)
+				break
+			}
+		}
+	}
+
+	numbers, missing, err := readExtractedNumbers(strings.NewReader("GET /a 200 12ms\nconnection reset\nGET /b 404 8ms\n"))
+	if err != nil {
+		t.Fatalf("readExtractedNumbers returned error: %v", err)
+	}
+	if missing != 1 {
+		t.Errorf("readExtractedNumbers: got %d lines without numbers, expected 1", missing)
+	}
+	expected := []float64{200, 12, 404, 8}
+	if len(numbers) != len(expected) {
+		t.Fatalf("readExtractedNumbers: got %v, expected %v", numbers, expected)
+	}
+	for i := range expected {
+		if numbers[i] != expected[i] {
+			t.Errorf("readExtractedNumbers: got %v, expected %v", numbers, expected)
+			break
+		}
+	}
+}
+
+func TestAsymmetricTrimmedMean(t *testing.T) {
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+
+	symmetric, err := calculateTrimmedMean(sorted, 10, 10)
+	if err != nil {
+		t.Fatalf("calculateTrimmedMean returned error: %v", err)
+	}
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 10, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if math.Abs(symmetric-stats.TrimmedMean) > 1e-9 {
+		t.Errorf("symmetric 10%%: got %v, expected %v from -t 10", symmetric, stats.TrimmedMean)
+	}
+
+	// 5% of 31 drops 1 value from the low tail; 10% drops 3 from the high tail (100, 150, 95)
+	asymmetric, err := calculateTrimmedMean(sorted, 5, 10)
+	if err != nil {
+		t.Fatalf("calculateTrimmedMean returned error: %v", err)
+	}
+	expected := (1603.5 - 3 - 95 - 100 - 150) / 27
+	if math.Abs(asymmetric-expected) > 1e-9 {
+		t.Errorf("asymmetric 5%%/10%%: got %v, expected %v", asymmetric, expected)
+	}
+	if asymmetric == symmetric {
+		t.Errorf("asymmetric trimming should differ from symmetric 10%%, both %v", asymmetric)
+	}
+
+	// The guard applies to the combined trim: 50% + 50% of 4 values leaves none
+	if _, err := calculateTrimmedMean([]float64{1, 2, 3, 4}, 50, 50); err == nil {
+		t.Error("expected an error when the combined trim removes every value")
+	}
+	if got, err := calculateTrimmedMean([]float64{1, 2, 3, 4}, 25, 50); err != nil || got != 2 {
+		t.Errorf("25%%/50%% of 4 values: got %v (err %v), expected 2", got, err)
+	}
+}
+
+func TestWriteHistogramJSON(t *testing.T) {
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+
+	var buf bytes.Buffer
+	if err := writeHistogramJSON(&buf, computeHistogramBins(sorted, 16), sorted); err != nil {
+		t.Fatalf("writeHistogramJSON returned error: %v", err)
+	}
+	var bins []HistogramBin
+	if err := json.Unmarshal(buf.Bytes(), &bins); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if len(bins) != 16 {
+		t.Fatalf("bins: got %d, expected 16", len(bins))
+	}
+	total := 0
+	for _, b := range bins {
+		total += b.Count
+	}
+	if total != len(testData) {
+		t.Errorf("counts sum: got %d, expected %d", total, len(testData))
+	}
+	if bins[0].Lower != 3 || bins[15].Upper != 150 {
+		t.Errorf("range: got [%v, %v], expected [3, 150]", bins[0].Lower, bins[15].Upper)
+	}
+	for i := 1; i < len(bins); i++ {
+		if bins[i].Lower != bins[i-1].Upper {
+			t.Errorf("bin %d lower %v does not match bin %d upper %v", i, bins[i].Lower, i-1, bins[i-1].Upper)
+		}
+	}
+
+	buf.Reset()
+	constant := []float64{7, 7, 7}
+	if err := writeHistogramJSON(&buf, computeHistogramBins(constant, 16), constant); err != nil {
+		t.Fatalf("writeHistogramJSON returned error: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != `[{"lower":7,"upper":7,"count":3}]` {
+		t.Errorf("constant data: got %s", got)
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	var input strings.Builder
+	for i := 1; i <= 2500; i++ {
+		fmt.Fprintf(&input, "%d\n", i)
+	}
+
+	var reported []int
+	reader := &progressReader{r: strings.NewReader(input.String()), every: 1000, report: func(lines int) {
+		reported = append(reported, lines)
+	}}
+	numbers, err := readNumbers(reader)
+	if err != nil {
+		t.Fatalf("readNumbers returned error: %v", err)
+	}
+	if len(numbers) != 2500 {
+		t.Errorf("numbers: got %d, expected 2500", len(numbers))
+	}
+	if len(reported) != 2 || reported[0] != 1000 || reported[1] != 2000 {
+		t.Errorf("progress reports: got %v, expected [1000 2000]", reported)
+	}
+}
+
+func TestReadColumnNumbers(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		column          int
+		delim           string
+		expected        []float64
+		expectedMissing int
+	}{
+		{"second CSV column", "a,10\nb,20", 2, ",", []float64{10, 20}, 0},
+		{"first column", "1,x\n2,y\n", 1, ",", []float64{1, 2}, 0},
+		{"out-of-range line skipped", "a,10\nb\nc,30\n", 2, ",", []float64{10, 30}, 1},
+		{"tab delimiter with padding", "a\t 5 \nb\t6\n", 2, "\t", []float64{5, 6}, 0},
+		{"invalid number skipped", "a,10\nb,xyz\n", 2, ",", []float64{10}, 1},
+		{"non-finite numbers skipped", "a,10\nb,NaN\nc,Inf\nd,-Inf\n", 2, ",", []float64{10}, 3},
+	}
+	for _, tt := range tests {
+		got, missing, err := readColumnNumbers(strings.NewReader(tt.input), tt.column, tt.delim)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if len(got) != len(tt.expected) {
+			t.Errorf("%s: got %v, expected %v", tt.name, got, tt.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("%s: got %v, expected %v", tt.name, got, tt.expected)
+				break
+			}
+		}
+		if missing != tt.expectedMissing {
+			t.Errorf("%s: missing got %d, expected %d", tt.name, missing, tt.expectedMissing)
+		}
+	}
+}
+
+func TestColumnNaN(t *testing.T) {
+	// NaN used to reach the histogram through -column and panic in countBins
+	cmd := exec.Command("go", "run", "stats.go", "-column", "1", "-count-missing", "-")
+	cmd.Stdin = strings.NewReader("1\n2\nNaN\n4\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, output)
+	}
+	for _, line := range []string{"Count:             3\n", "Skipped/missing:   1\n"} {
+		if !strings.Contains(string(output), line) {
+			t.Errorf("expected %q, got:\n%s", line, output)
+		}
+	}
+}
+
+func TestReadWeightedColumnNumbers(t *testing.T) {
+	input := "a,1,1\nb,2,1\n\nc,3,x\nd,4,-2\ne,5\nf,6,10\n"
+	values, weights, missing, err := readWeightedColumnNumbers(strings.NewReader(input), 2, 3, ",")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !floatSliceEquals(values, []float64{1, 2, 6}) || !floatSliceEquals(weights, []float64{1, 1, 10}) {
+		t.Errorf("got values %v, weights %v; expected [1 2 6] and [1 1 10]", values, weights)
+	}
+	// a blank line, an invalid weight, a negative weight, and a missing weight field
+	if missing != 4 {
+		t.Errorf("missing: got %d, expected 4", missing)
+	}
+}
+
+func TestWeightColumnReport(t *testing.T) {
+	cmd := exec.Command("go", "run", "stats.go", "-column", "1", "-weight-column", "2", "-")
+	cmd.Stdin = strings.NewReader("1,1\n2,1\n3,10\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, output)
+	}
+	out := string(output)
+	for _, line := range []string{"Median (p50):      2\n", "Weighted Median:   3\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected %q, got:\n%s", line, out)
+		}
+	}
+}
+
+func TestReadAllColumns(t *testing.T) {
+	input := "latency,size\n10,100\n20,300\n30,200\n"
+	reader, header, err := skipLines(strings.NewReader(input), 1)
+	if err != nil {
+		t.Fatalf("skipLines returned error: %v", err)
+	}
+	if header != "latency,size" {
+		t.Errorf("header: got %q, expected %q", header, "latency,size")
+	}
+
+	columns, _, err := readAllColumns(reader, ",")
+	if err != nil {
+		t.Fatalf("readAllColumns returned error: %v", err)
+	}
+	if len(columns) != 2 {
+		t.Fatalf("columns: got %d, expected 2", len(columns))
+	}
+
+	names := columnNames(header, ",", len(columns))
+	tests := []struct {
+		name         string
+		expectedMean float64
+		expectedMax  float64
+	}{
+		{"latency", 20, 30},
+		{"size", 200, 300},
+	}
+	for i, tt := range tests {
+		if names[i] != tt.name {
+			t.Errorf("column %d name: got %q, expected %q", i+1, names[i], tt.name)
+		}
+		stats, err := computeStats(columns[i], nil, 1.5, 16, 0, 0, StatsOptions{})
+		if err != nil {
+			t.Fatalf("computeStats(%s) returned error: %v", tt.name, err)
+		}
+		if stats.Count != 3 || stats.Mean != tt.expectedMean || stats.Max != tt.expectedMax {
+			t.Errorf("%s: got count %d, mean %v, max %v; expected 3, %v, %v", tt.name, stats.Count, stats.Mean, stats.Max, tt.expectedMean, tt.expectedMax)
+		}
+	}
+
+	// Without a header, columns are labeled by 1-based index
+	if got := columnNames("", ",", 2); got[0] != "Column 1" || got[1] != "Column 2" {
+		t.Errorf("columnNames without header: got %v", got)
+	}
+
+	// NaN and Inf cells are skipped like any other invalid field
+	columns, missing, err := readAllColumns(strings.NewReader("1,NaN\n\n2,5\nInf,6\n"), ",")
+	if err != nil {
+		t.Fatalf("readAllColumns returned error: %v", err)
+	}
+	if !floatSliceEquals(columns[0], []float64{1, 2}) || !floatSliceEquals(columns[1], []float64{5, 6}) {
+		t.Errorf("non-finite cells: got %v, expected [[1 2] [5 6]]", columns)
+	}
+	// one non-finite cell per column, plus the blank line in both
+	if len(missing) != 2 || missing[0] != 2 || missing[1] != 2 {
+		t.Errorf("skipped per column: got %v, expected [2 2]", missing)
+	}
+}
+
+func TestFindOutOfRange(t *testing.T) {
+	violations := findOutOfRange(testData, math.Inf(-1), 100)
+	if len(violations) != 1 || testData[violations[0]] != 150 {
+		t.Fatalf("expect-max 100: got indices %v, expected only the index of 150", violations)
+	}
+	out := formatViolations(testData, violations, math.Inf(-1), 100)
+	if !strings.Contains(out, "value #29: 150") {
+		t.Errorf("expected the listing to name value #29 (150), got:\n%s", out)
+	}
+
+	if got := findOutOfRange(testData, 3, 150); len(got) != 0 {
+		t.Errorf("inclusive bounds: got %v, expected none", got)
+	}
+	if got := findOutOfRange(testData, 10, math.Inf(1)); len(got) != 3 {
+		t.Errorf("expect-min 10: got %d violations, expected 3 (3, 5, 7.75 below 10)", len(got))
+	}
+
+	// The listing is capped
+	many := make([]float64, 25)
+	indices := findOutOfRange(many, 1, 2)
+	out = formatViolations(many, indices, 1, 2)
+	if !strings.Contains(out, "... and 15 more") {
+		t.Errorf("expected the listing to be capped at %d, got:\n%s", maxViolationsListed, out)
+	}
+}
+
+func TestDump(t *testing.T) {
+	// There is no -abs transform, so a filter and the log transform show that processing is applied
+	cmd := exec.Command("go", "run", "stats.go", "-dump", "-exclude-zeros", "-l", "-")
+	cmd.Stdin = strings.NewReader("1\n0\n100\n0.5\n")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Fields(string(output))
+	expected := []float64{0, math.Log(100), math.Log(0.5)}
+	if len(lines) != len(expected) {
+		t.Fatalf("dumped values: got %q, expected %d values", lines, len(expected))
+	}
+	for i, line := range lines {
+		v, err := strconv.ParseFloat(line, 64)
+		if err != nil {
+			t.Fatalf("line %d: %q is not a number", i+1, line)
+		}
+		if v != expected[i] {
+			t.Errorf("line %d: got %v, expected %v", i+1, v, expected[i])
+		}
+	}
+}
+
+func TestStandardNormalQuantile(t *testing.T) {
+	tests := []struct {
+		p        float64
+		expected float64
+	}{
+		{0.5, 0},
+		{0.975, 1.959963985},
+		{0.025, -1.959963985},
+		{0.8413447461, 1},
+		{0.001, -3.090232306},
+	}
+	for _, tt := range tests {
+		got := standardNormalQuantile(tt.p)
+		if math.Abs(got-tt.expected) > 1e-6 {
+			t.Errorf("standardNormalQuantile(%v): got %v, expected %v", tt.p, got, tt.expected)
+		}
+	}
+}
+
+func TestQQCorrelation(t *testing.T) {
+	// Heights-like data that follows a bell curve
+	normal := []float64{
+		160, 162, 163, 165, 165, 166, 167, 168, 168, 169,
+		170, 170, 170, 171, 171, 172, 172, 173, 174, 175,
+		175, 176, 177, 178, 180, 181, 183,
+	}
+	r, ok := calculateQQCorrelation(normal)
+	if !ok {
+		t.Fatal("expected a valid QQ correlation")
+	}
+	if r <= 0.98 {
+		t.Errorf("normal-ish data: got %v, expected > 0.98", r)
+	}
+
+	// Strongly skewed data fits the normal line less well
+	skewed := []float64{1, 1, 1, 2, 2, 3, 4, 8, 16, 32, 64, 128}
+	rSkewed, _ := calculateQQCorrelation(skewed)
+	if rSkewed >= r {
+		t.Errorf("skewed data: got %v, expected less than normal-ish data's %v", rSkewed, r)
+	}
+
+	if _, ok := calculateQQCorrelation([]float64{5, 5, 5}); ok {
+		t.Error("constant data: expected ok to be false")
+	}
+}
+
+func TestNormalQuantile(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if got := normalQuantile(0.5, stats.Mean, stats.StdDev); math.Abs(got-stats.Mean) > 1e-9 {
+		t.Errorf("normalQuantile(0.5): got %v, expected the mean %v", got, stats.Mean)
+	}
+	// One standard deviation above the mean is the 84.13th percentile
+	if got := normalQuantile(0.8413447461, 100, 15); math.Abs(got-115) > 1e-5 {
+		t.Errorf("normalQuantile(0.8413): got %v, expected 115", got)
+	}
+
+	out := formatTheoreticalPercentile(95, stats, stats.P95)
+	expected := formatFloat(normalQuantile(0.95, stats.Mean, stats.StdDev))
+	if !strings.Contains(out, "Normal Model (p95): "+expected) {
+		t.Errorf("expected the normal model p95 %s, got:\n%s", expected, out)
+	}
+	if !strings.Contains(out, "Empirical (p95):    97.5") {
+		t.Errorf("expected the empirical p95 97.5, got:\n%s", out)
+	}
+}
+
+func TestLogHistogramBins(t *testing.T) {
+	data := []float64{1, 10, 100, 1000}
+	nonEmpty := func(bins []HistogramBin) int {
+		n := 0
+		for _, b := range bins {
+			if b.Count > 0 {
+				n++
+			}
+		}
+		return n
+	}
+
+	logBins, err := computeLogHistogramBins(data, 16)
+	if err != nil {
+		t.Fatalf("computeLogHistogramBins returned error: %v", err)
+	}
+	if got := nonEmpty(logBins); got != 4 {
+		t.Errorf("log bins: %d non-empty, expected each value in its own bin", got)
+	}
+	// Linear bins collapse 1 and 10 into the first bin
+	if got := nonEmpty(computeHistogramBins(data, 16)); got != 3 {
+		t.Errorf("linear bins: %d non-empty, expected 3", got)
+	}
+
+	// Each log bin spans the same ratio
+	ratio := logBins[0].Upper / logBins[0].Lower
+	for i, b := range logBins {
+		if math.Abs(b.Upper/b.Lower-ratio) > 1e-9 {
+			t.Errorf("bin %d ratio: got %v, expected %v", i, b.Upper/b.Lower, ratio)
+		}
+	}
+	if logBins[0].Lower != 1 || logBins[15].Upper != 1000 {
+		t.Errorf("range: got [%v, %v], expected [1, 1000]", logBins[0].Lower, logBins[15].Upper)
+	}
+
+	if _, err := computeLogHistogramBins([]float64{0, 1, 2}, 16); err == nil {
+		t.Error("expected an error for non-positive data")
+	}
+}
+
+func TestPercentOfMean(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	// StdDev as a percent of the mean is the CV
+	pct, ok := percentOfMean(stats.StdDev, stats.Mean)
+	if !ok {
+		t.Fatal("percentOfMean: got ok=false, expected true")
+	}
+	if !floatEquals(pct, 64.9097) || !floatEquals(pct, stats.CV) {
+		t.Errorf("StdDev%%: got %v, expected CV %v", pct, stats.CV)
+	}
+
+	out := formatReport(stats, ReportOptions{ShowRelative: true}, 20)
+	if !strings.Contains(out, "33.5751 (64.9097% of mean)") {
+		t.Errorf("expected Std Deviation with percent of mean in output:\n%s", out)
+	}
+
+	if _, ok := percentOfMean(5, 0); ok {
+		t.Error("percentOfMean with zero mean: got ok=true, expected false")
+	}
+}
+
+func TestBootstrapSeedAuto(t *testing.T) {
+	// Same data hashes to the same seed; input order matters
+	if seedFromData(testData) != seedFromData(append([]float64(nil), testData...)) {
+		t.Error("seedFromData: identical data produced different seeds")
+	}
+	if seedFromData([]float64{1, 2}) == seedFromData([]float64{2, 1}) {
+		t.Error("seedFromData: reordered data produced the same seed")
+	}
+
+	run := func() string {
+		cmd := exec.Command("go", "run", "stats.go", "-bootstrap", "500", "-seed", "auto", "test_data.txt")
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out := string(output)
+		idx := strings.Index(out, "--- Bootstrap Confidence Interval ---")
+		if idx < 0 {
+			t.Fatalf("expected bootstrap section in output:\n%s", out)
+		}
+		return out[idx:]
+	}
+	first, second := run(), run()
+	if first != second {
+		t.Errorf("-seed auto runs differ:\n%s\nvs\n%s", first, second)
+	}
+
+	lower, upper := bootstrapMeanCI(testData, 500, 0.95, rand.New(rand.NewPCG(seedFromData(testData), 0)))
+	if !(lower < 51.7258 && 51.7258 < upper) {
+		t.Errorf("bootstrap CI [%v, %v] does not contain the mean", lower, upper)
+	}
+}
+
+func TestGeometricMeanCI(t *testing.T) {
+	ci, err := geometricMeanCI([]float64{1, 10, 100}, 0.95)
+	if err != nil {
+		t.Fatalf("geometricMeanCI returned error: %v", err)
+	}
+	if !floatEquals(ci.Mean, 10) {
+		t.Errorf("Mean: got %v, expected 10", ci.Mean)
+	}
+	// ln values 0, ln 10, ln 100 have a standard deviation of ln 10
+	if !floatEquals(ci.GeometricSD, 10) {
+		t.Errorf("GeometricSD: got %v, expected 10", ci.GeometricSD)
+	}
+	// The interval is symmetric on the multiplicative scale
+	if !floatEquals(ci.Upper/ci.Mean, ci.Factor) || !floatEquals(ci.Mean/ci.Lower, ci.Factor) {
+		t.Errorf("interval [%v, %v] is not Mean ×/÷ %v", ci.Lower, ci.Upper, ci.Factor)
+	}
+	if !(ci.Lower < 10 && 10 < ci.Upper) {
+		t.Errorf("interval [%v, %v] does not contain the geometric mean", ci.Lower, ci.Upper)
+	}
+
+	if _, err := geometricMeanCI([]float64{1, 0, 100}, 0.95); err == nil {
+		t.Error("expected an error for non-positive data")
+	}
+	if _, err := geometricMeanCI([]float64{5}, 0.95); err == nil {
+		t.Error("expected an error for a single value")
+	}
+}
+
+func TestStripUnits(t *testing.T) {
+	numbers, missing, err := readNumbersStrippingUnits(strings.NewReader("42.5ms\n10\n"))
+	if err != nil {
+		t.Fatalf("readNumbersStrippingUnits returned error: %v", err)
+	}
+	expected := []float64{42.5, 10}
+	if missing != 0 || len(numbers) != len(expected) {
+		t.Fatalf("got %v (%d missing), expected %v", numbers, missing, expected)
+	}
+	for i := range expected {
+		if numbers[i] != expected[i] {
+			t.Errorf("value %d: got %v, expected %v", i, numbers[i], expected[i])
+		}
+	}
+
+	tests := []struct {
+		token    string
+		expected float64
+		ok       bool
+	}{
+		{"3.2s", 3.2, true},
+		{"10 MB", 10, true},
+		{"1e3", 1000, true},
+		{"-7kb", -7, true},
+		{"ms", 0, false},
+		{"12ms5", 0, false},
+	}
+	for _, tc := range tests {
+		got, err := parseWithUnits(tc.token)
+		if (err == nil) != tc.ok || (tc.ok && got != tc.expected) {
+			t.Errorf("parseWithUnits(%q): got %v, %v; expected %v, ok=%v", tc.token, got, err, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestSignBreakdown(t *testing.T) {
+	stats, err := computeStats([]float64{-2, -1, 0, 3, 4}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if stats.NegativeCount != 2 || stats.ZeroCount != 1 || stats.PositiveCount != 2 {
+		t.Errorf("counts: got %d negative, %d zero, %d positive; expected 2, 1, 2", stats.NegativeCount, stats.ZeroCount, stats.PositiveCount)
+	}
+	if stats.NegativeSum != -3 || stats.PositiveSum != 7 {
+		t.Errorf("sums: got %v negative, %v positive; expected -3, 7", stats.NegativeSum, stats.PositiveSum)
+	}
+	if !stats.HasNegativeData {
+		t.Error("HasNegativeData: got false, expected true")
+	}
+
+	out := formatSignBreakdown(stats)
+	for _, want := range []string{"Positive:  2 (40%), sum 7", "Negative:  2 (40%), sum -3", "Zero:      1 (20%), sum 0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestQuartileMarkers(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+	bins := computeHistogramBins(sorted, 16)
+
+	// Range 3..150 in 16 bins of width 9.1875: Q1=27.5 -> bin 2, median=50 -> bin 5, Q3=72.625 -> bin 7
+	got := quartileMarkers(bins, stats.Q1, stats.Median, stats.Q3)
+	if got != "  |  | |" {
+		t.Errorf("markers: got %q, expected %q", got, "  |  | |")
+	}
+	if n := strings.Count(got, "|"); n != 3 {
+		t.Errorf("marker count: got %d, expected 3", n)
+	}
+
+	if binIndex(bins, 150) != 15 || binIndex(bins, 3) != 0 || binIndex(bins, 2) != -1 {
+		t.Errorf("binIndex edges: got %d, %d, %d; expected 15, 0, -1", binIndex(bins, 150), binIndex(bins, 3), binIndex(bins, 2))
+	}
+	if quartileMarkers(nil, 1, 2, 3) != "" {
+		t.Error("expected no markers without bins")
+	}
+}
+
+func TestModalBin(t *testing.T) {
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+	bin := modalBin(computeHistogramBins(sorted, 16))
+	if bin == nil {
+		t.Fatal("modalBin returned nil")
+	}
+	if !(bin.Lower <= 50 && 50 < bin.Upper) {
+		t.Errorf("modal bin [%v, %v) does not contain 50", bin.Lower, bin.Upper)
+	}
+	if bin.Count != 5 {
+		t.Errorf("modal bin count: got %d, expected 5", bin.Count)
+	}
+	if modalBin(nil) != nil {
+		t.Error("modalBin(nil): expected nil")
+	}
+}
+
+func TestFixedPrecision(t *testing.T) {
+	if got := formatFixedSlice([]float64{50}, 2); got != "[50.00]" {
+		t.Errorf("formatFixedSlice: got %q, expected %q", got, "[50.00]")
+	}
+	if got := formatFixedSlice([]float64{62.5, 3}, 1); got != "[62.5 3.0]" {
+		t.Errorf("formatFixedSlice: got %q, expected %q", got, "[62.5 3.0]")
+	}
+
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	out := formatReport(stats, ReportOptions{FixedPrecision: true, Precision: 2}, 19)
+	for _, want := range []string{"Mode:              50.00\n", "Outliers:          [150.00]\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestSkewnessTest(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	test, err := skewnessTest(stats.Skewness, stats.Count)
+	if err != nil {
+		t.Fatalf("skewnessTest returned error: %v", err)
+	}
+	// n=31: SE = sqrt(6*31*30 / (29*32*34)) ≈ 0.4205, z ≈ 0.7271/0.4205 ≈ 1.7289
+	if !floatEquals(test.SE, 0.4205) {
+		t.Errorf("SE: got %v, expected 0.4205", test.SE)
+	}
+	if !floatEquals(test.Z, 1.7289) {
+		t.Errorf("Z: got %v, expected 1.7289", test.Z)
+	}
+	if test.Significant {
+		t.Error("Significant: got true, expected false (|z| < 1.96)")
+	}
+	if out := formatShapeTest(test, "right skewed", "left skewed", "symmetry"); !strings.Contains(out, "not significant at 5%") {
+		t.Errorf("expected a not-significant verdict, got:\n%s", out)
+	}
+
+	strong := newShapeTest(-1.5, skewnessStdError(31))
+	if out := formatShapeTest(strong, "right skewed", "left skewed", "symmetry"); !strings.Contains(out, "significant at 5% (left skewed)") {
+		t.Errorf("expected a left-skewed verdict, got:\n%s", out)
+	}
+
+	if _, err := skewnessTest(0, 2); err == nil {
+		t.Error("expected an error for fewer than 3 values")
+	}
+}
+
+func TestKurtosisTest(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	test, err := kurtosisTest(stats.Kurtosis, stats.Count)
+	if err != nil {
+		t.Fatalf("kurtosisTest returned error: %v", err)
+	}
+	// n=31: SE = 2 * 0.4205 * sqrt(960 / (28*36)) ≈ 0.8208, z ≈ 0.8884/0.8208 ≈ 1.0824
+	if !floatEquals(test.SE, 0.8208) {
+		t.Errorf("SE: got %v, expected 0.8208", test.SE)
+	}
+	if !floatEquals(test.Z, 1.0824) {
+		t.Errorf("Z: got %v, expected 1.0824", test.Z)
+	}
+	if test.Significant {
+		t.Error("Significant: got true, expected false (|z| < 1.96)")
+	}
+	if out := formatShapeTest(test, "heavy tails", "light tails", "normal tails"); !strings.Contains(out, "not significant at 5% (consistent with normal tails)") {
+		t.Errorf("expected a not-significant verdict, got:\n%s", out)
+	}
+
+	if _, err := kurtosisTest(0, 3); err == nil {
+		t.Error("expected an error for fewer than 4 values")
+	}
+}
+
+func TestTDigestQuantile(t *testing.T) {
+	d := newTDigest(tDigestCompression)
+	for _, v := range testData {
+		d.Add(v)
+	}
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if got := d.Quantile(0.5); math.Abs(got-stats.Median) > 1 {
+		t.Errorf("median: got %v, expected %v within 1", got, stats.Median)
+	}
+	if d.Quantile(0) != stats.Min || d.Quantile(1) != stats.Max {
+		t.Errorf("ends: got %v and %v, expected %v and %v", d.Quantile(0), d.Quantile(1), stats.Min, stats.Max)
+	}
+
+	// A long stream stays within bounded memory and close to the exact quantiles
+	large := newTDigest(tDigestCompression)
+	n := 100000
+	for i := 0; i < n; i++ {
+		large.Add(float64((i * 7919) % n)) // 0..n-1 in scrambled order
+	}
+	if c := large.Centroids(); c > int(tDigestCompression) {
+		t.Errorf("centroids: got %d, expected at most %d", c, int(tDigestCompression))
+	}
+	for _, p := range []float64{0.25, 0.5, 0.75, 0.99} {
+		exact := p * float64(n-1)
+		if got := large.Quantile(p); math.Abs(got-exact) > 0.01*float64(n) {
+			t.Errorf("p%v: got %v, expected %v within 1%% of the range", p*100, got, exact)
+		}
+	}
+
+	if newTDigest(tDigestCompression).Quantile(0.5) != 0 {
+		t.Error("empty digest: expected 0")
+	}
+}
+
+func TestPercentileRank(t *testing.T) {
+	data := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		v        float64
+		expected float64
+	}{
+		{3, 30},
+		{7, 70},
+		{0, 0},
+		{10, 100},
+		{3.5, 30},
+	}
+	for _, tc := range tests {
+		if got, _ := percentileRank(data, tc.v); !floatEquals(got, tc.expected) {
+			t.Errorf("percentileRank(%v): got %v, expected %v", tc.v, got, tc.expected)
+		}
+	}
+
+	out := formatPercentileRanks(data, []float64{3, 7})
+	for _, want := range []string{"3:  30% (3 of 10 at or below)", "7:  70% (7 of 10 at or below)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestClusterMode(t *testing.T) {
+	mode, count := clusterMode([]float64{5.0, 1.02, 1.0, 1.01}, 0.05)
+	if !floatEquals(mode, 1.01) || count != 3 {
+		t.Errorf("clusterMode: got %v (%d values), expected 1.01 (3 values)", mode, count)
+	}
+
+	// Clusters do not chain through neighbors: 1 and 1.08 are more than 0.05 apart, so every
+	// cluster has two values and the lowest wins
+	mode, count = clusterMode([]float64{1, 1.04, 1.08, 2, 2.01}, 0.05)
+	if !floatEquals(mode, 1.02) || count != 2 {
+		t.Errorf("clusterMode chained: got %v (%d values), expected 1.02 (2 values)", mode, count)
+	}
+
+	// Evenly spaced data must not collapse into a single cluster whose mean is the data mean
+	even := make([]float64, 100)
+	for i := range even {
+		even[i] = float64(i + 1)
+	}
+	mode, count = clusterMode(even, 1)
+	if !floatEquals(mode, 1.5) || count != 2 {
+		t.Errorf("clusterMode evenly spaced: got %v (%d values), expected 1.5 (2 values)", mode, count)
+	}
+	mode, count = clusterMode(append(even, 50.2, 50.4, 50.6), 1)
+	if !floatEquals(mode, 50.44) || count != 5 {
+		t.Errorf("clusterMode evenly spaced with a dense spot: got %v (%d values), expected 50.44 (5 values)", mode, count)
+	}
+
+	// No values within tolerance: every cluster has one value
+	if _, count := clusterMode([]float64{1, 2, 3}, 0.5); count != 1 {
+		t.Errorf("clusterMode singletons: got count %d, expected 1", count)
+	}
+}
+
+func TestShowWork(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	out := formatShowWork(testData, stats)
+	for _, want := range []string{
+		"Mean = sum(x)/n = 1603.5/31 = 51.7258\n",
+		"Variance = sum((x-mean)^2)/(n-1) = 33818.5444/30 = 1127.2848\n",
+		"Std Deviation = sqrt(Variance) = sqrt(1127.2848) = 33.5751\n",
+		"Skewness = n/((n-1)(n-2)) * sum((x-mean)^3)/s^3 = 31/(30*29) * ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	single, err := computeStats([]float64{5}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if out := formatShowWork([]float64{5}, single); !strings.Contains(out, "undefined for n = 1") {
+		t.Errorf("expected an undefined variance note for one value, got:\n%s", out)
+	}
+}
+
+func TestModifiedZScores(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	scores := calculateModifiedZScores(testData, stats.Median, stats.MAD)
+	if len(scores) != stats.Count {
+		t.Fatalf("length: got %d, expected %d", len(scores), stats.Count)
+	}
+	// 150 is the IQR outlier: its score, 0.6745*(150-50)/25 ≈ 2.698, is the largest in magnitude
+	largest := 0
+	for i, v := range testData {
+		if math.Abs(scores[i]) > math.Abs(scores[largest]) {
+			largest = i
+		}
+		if v == stats.Median && scores[i] != 0 {
+			t.Errorf("modified z-score of the median: got %v, expected 0", scores[i])
+		}
+	}
+	if testData[largest] != 150 || !floatEquals(scores[largest], 2.698) {
+		t.Errorf("largest modified z-score: got %v for %v, expected 2.698 for 150", scores[largest], testData[largest])
+	}
+
+	if got := calculateModifiedZScores([]float64{4, 4, 4, 9}, 4, 0); got != nil {
+		t.Errorf("zero MAD: got %v, expected nil", got)
+	}
+}
+
+func TestReadSplitNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []float64
+		skipped  int
+	}{
+		{"a1b2c3.5", []float64{1, 2, 3.5}, 0},
+		{"x=-4, y=5\nz:6;7", []float64{-4, 5, 6, 7}, 0},
+		{"1-2 1.2.3 --8", []float64{1, 2, -8}, 1},
+		{"no numbers here. -", nil, 0},
+	}
+	for _, tc := range tests {
+		got, skipped, err := readSplitNumbers(strings.NewReader(tc.input))
+		if err != nil {
+			t.Fatalf("readSplitNumbers(%q) returned error: %v", tc.input, err)
+		}
+		if !floatSliceEquals(got, tc.expected) || skipped != tc.skipped {
+			t.Errorf("readSplitNumbers(%q): got %v (%d skipped), expected %v (%d skipped)", tc.input, got, skipped, tc.expected, tc.skipped)
+		}
+	}
+}
+
+func TestStdDevCI(t *testing.T) {
+	// Chi-square critical values from standard tables
+	tests := []struct {
+		p        float64
+		df       int
+		expected float64
+	}{
+		{0.975, 30, 46.9792},
+		{0.025, 30, 16.7908},
+		{0.95, 1, 3.8415},
+		{0.5, 2, 1.3863},
+	}
+	for _, tc := range tests {
+		if got := chiSquareQuantile(tc.p, tc.df); math.Abs(got-tc.expected) > 1e-3 {
+			t.Errorf("chiSquareQuantile(%v, %d): got %v, expected %v", tc.p, tc.df, got, tc.expected)
+		}
+	}
+
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	lower, upper, err := stdDevCI(stats.Variance, stats.Count, 0.95)
+	if err != nil {
+		t.Fatalf("stdDevCI returned error: %v", err)
+	}
+	if !(lower < stats.StdDev && stats.StdDev < upper) {
+		t.Errorf("CI [%v, %v] does not bracket StdDev %v", lower, upper, stats.StdDev)
+	}
+	// sqrt(33818.5444/46.9792) and sqrt(33818.5444/16.7908)
+	if math.Abs(lower-26.8302) > 1e-3 || math.Abs(upper-44.8789) > 1e-3 {
+		t.Errorf("CI: got [%v, %v], expected [26.8302, 44.8789]", lower, upper)
+	}
+
+	if _, _, err := stdDevCI(0, 1, 0.95); err == nil {
+		t.Error("expected an error for a single value")
+	}
+}
+
+func TestBaselineSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+
+	save := exec.Command("go", "run", "stats.go", "-save-baseline", path, "test_data.txt")
+	if err := save.Run(); err != nil {
+		t.Fatalf("saving baseline: %v", err)
+	}
+	baseline, err := loadBaseline(path)
+	if err != nil {
+		t.Fatalf("loadBaseline returned error: %v", err)
+	}
+	if baseline.Count != 31 || !floatEquals(baseline.Mean, 51.7258) {
+		t.Errorf("baseline: got Count %d, Mean %v; expected 31, 51.7258", baseline.Count, baseline.Mean)
+	}
+
+	// Shift every value up by 10 so the mean drifts by about 19%
+	var drifted strings.Builder
+	for _, v := range testData {
+		fmt.Fprintf(&drifted, "%s\n", formatFloat(v+10))
+	}
+	check := exec.Command("go", "run", "stats.go", "-check-baseline", path, "-tolerance", "5", "-")
+	check.Stdin = strings.NewReader(drifted.String())
+	output, err := check.Output()
+	// go run reports the program's exit status on stderr and exits 1 itself
+	var exitErr *exec.ExitError
+	want := fmt.Sprintf("exit status %d", baselineDriftExitCode)
+	if !errors.As(err, &exitErr) || !strings.Contains(string(exitErr.Stderr), want) {
+		t.Fatalf("expected %q, got %v", want, err)
+	}
+	if !strings.Contains(string(output), "Status:         ALERT (beyond ±5%)") {
+		t.Errorf("expected an ALERT status in output:\n%s", output)
+	}
+
+	// Within tolerance
+	current := *baseline
+	current.Mean = baseline.Mean * 1.04
+	if drift, ok := meanDrift(baseline, &current, 5); !ok || !floatEquals(drift, 4) {
+		t.Errorf("meanDrift: got %v (ok=%v), expected 4 within tolerance", drift, ok)
+	}
+}
+
+func TestFormatFields(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	got := formatFields(stats, []string{"mean", "max"})
+	expected := "mean: 51.7258\nmax:  150\n"
+	if got != expected {
+		t.Errorf("formatFields: got %q, expected %q", got, expected)
+	}
+
+	for _, name := range fieldNames() {
+		if _, ok := lookupField(name); !ok {
+			t.Errorf("lookupField(%q): not found", name)
+		}
+	}
+	if _, ok := lookupField("average"); ok {
+		t.Error("lookupField(\"average\"): expected not found")
+	}
+}
+
+func TestDetectClipping(t *testing.T) {
+	// A signal saturating at 1023, as from a 10-bit ADC
+	data := []float64{12, 340, 1023, 511, 1023, 87, 1023, 900, 1023, 640}
+	stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	atMin, atMax := countAtExtremes(data, stats.Min, stats.Max)
+	if atMin != 1 || atMax != 4 {
+		t.Errorf("counts: got %d at Min and %d at Max, expected 1 and 4", atMin, atMax)
+	}
+	out := formatClipping(stats, atMin, atMax)
+	if !strings.Contains(out, "WARNING: possible saturation at Max (4 values, 40% > 5%)") {
+		t.Errorf("expected a saturation warning at Max, got:\n%s", out)
+	}
+	if strings.Contains(out, "saturation at Min") {
+		t.Errorf("unexpected saturation warning at Min:\n%s", out)
+	}
+
+	stats, err = computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	atMin, atMax = countAtExtremes(testData, stats.Min, stats.Max)
+	if out := formatClipping(stats, atMin, atMax); !strings.Contains(out, "No clipping detected") {
+		t.Errorf("expected no clipping for testData, got:\n%s", out)
+	}
+}
+
+func TestTrimean(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	// (27.5 + 2*50 + 72.625) / 4
+	if !floatEquals(stats.Trimean, 50.03125) {
+		t.Errorf("Trimean: got %v, expected 50.03125", stats.Trimean)
+	}
+	if out := formatReport(stats, ReportOptions{}, 19); !strings.Contains(out, "Trimean:           50.0312\n") {
+		t.Errorf("expected Trimean under central tendency:\n%s", out)
+	}
+}
+
+func TestNormalityChecks(t *testing.T) {
+	conclude := func(data []float64) ([]normalityCheck, string) {
+		t.Helper()
+		stats, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
+		if err != nil {
+			t.Fatalf("computeStats returned error: %v", err)
+		}
+		checks, err := normalityChecks(data, stats)
+		if err != nil {
+			t.Fatalf("normalityChecks returned error: %v", err)
+		}
+		return checks, normalityConclusion(checks)
+	}
+
+	symmetric := []float64{1, 2, 2, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 6, 6, 7}
+	if _, got := conclude(symmetric); got != "likely normal (4 of 4 checks pass)" {
+		t.Errorf("symmetric data: got %q", got)
+	}
+
+	skewed := []float64{1, 1, 1, 2, 2, 3, 5, 8, 13, 21, 34, 55, 89, 144}
+	if _, got := conclude(skewed); got != "likely not normal (0 of 4 checks pass)" {
+		t.Errorf("skewed data: got %q", got)
+	}
+
+	// testData's outlier bends the QQ plot, but the other three checks pass
+	checks, got := conclude(testData)
+	if checks[1].Name != "QQ Correlation" || checks[1].Pass {
+		t.Errorf("testData QQ check: got %+v, expected a failure", checks[1])
+	}
+	if got != "likely normal (3 of 4 checks pass)" {
+		t.Errorf("testData: got %q", got)
+	}
+
+	tie := []normalityCheck{{Pass: true}, {Pass: true}, {Pass: false}, {Pass: false}}
+	if got := normalityConclusion(tie); got != "inconclusive (2 of 4 checks pass)" {
+		t.Errorf("tie: got %q", got)
+	}
+
+	if jb, p := jarqueBera(0, 0, 50); jb != 0 || !floatEquals(p, 1) {
+		t.Errorf("jarqueBera(0, 0): got JB %v, p %v; expected 0, 1", jb, p)
+	}
+}
+
+func TestPercentWithinSD(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	one, two, three := stats.WithinOneSD, stats.WithinTwoSD, stats.WithinThreeSD
+	if !(one <= two && two <= three && three <= 100) {
+		t.Errorf("percentages not increasing and <= 100: %v, %v, %v", one, two, three)
+	}
+	// 150 is the only value more than 2 SD from the mean (51.73 ± 67.15)
+	if !floatEquals(two, 30.0/31*100) || three != 100 {
+		t.Errorf("within 2 and 3 SD: got %v and %v, expected %v and 100", two, three, 30.0/31*100)
+	}
+
+	if out := formatEmpiricalRule(stats); !strings.Contains(out, "Within 3 SD:  100% (normal: 99.73%)") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestStemLeaf(t *testing.T) {
+	data := []float64{-23, -21, -5, 0, 4, 12, 12, 31, 47}
+	out := formatStemLeaf(data)
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "Leaf unit: 1 ") {
+		t.Fatalf("unexpected leaf unit line: %q", lines[0])
+	}
+	var got []float64
+	for _, line := range lines[1:] {
+		stemStr, leaves, ok := strings.Cut(line, " |")
+		if !ok {
+			t.Fatalf("malformed line: %q", line)
+		}
+		stemStr = strings.TrimSpace(stemStr)
+		stem, err := strconv.Atoi(stemStr)
+		if err != nil {
+			t.Fatalf("bad stem %q: %v", stemStr, err)
+		}
+		negative := strings.HasPrefix(stemStr, "-")
+		if negative {
+			stem = -stem
+		}
+		for _, c := range strings.TrimSpace(leaves) {
+			v := float64(stem*10 + int(c-'0'))
+			if negative {
+				v = -v
+			}
+			got = append(got, v)
+		}
+	}
+	if !floatSliceEquals(got, data) {
+		t.Errorf("reconstructed %v, expected %v\n%s", got, data, out)
+	}
+}
+
+func TestBoxPlot(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	out := formatBoxPlot(stats, withinFences(testData, stats, 1.5), 60)
+	line := strings.SplitN(out, "\n", 2)[0]
+
+	q1, q3 := strings.IndexRune(line, '['), strings.IndexRune(line, ']')
+	median := boxPlotColumn(stats.Median, stats.Min, stats.Max, 60)
+	if q1 < 0 || q3 < 0 || !(q1 < median && median < q3) {
+		t.Errorf("median column %d not between Q1 %d and Q3 %d:\n%s", median, q1, q3, out)
+	}
+	if line[median] != '|' {
+		t.Errorf("expected median marker at column %d:\n%s", median, out)
+	}
+	// 150 is the only outlier and sits at the right edge
+	if !strings.HasSuffix(line, "o") || strings.Count(line, "o") != 1 {
+		t.Errorf("expected a single outlier marker at the end:\n%s", out)
+	}
+
+	// with -k 0.1 both values are outliers, so there are no whisker ends to draw
+	pair := []float64{1, 100}
+	stats, err = computeStats(pair, nil, 0.1, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	out = formatBoxPlot(stats, withinFences(pair, stats, 0.1), 60)
+	if line := strings.SplitN(out, "\n", 2)[0]; !strings.Contains(line, "[") || !strings.Contains(line, "]") {
+		t.Errorf("no values within the fences: expected the box to still be drawn:\n%s", out)
+	}
+
+	// the median shares a column with Q1 here, and the box edge must not be overwritten
+	skewed := []float64{1, 2, 3, 4, 100}
+	stats, err = computeStats(skewed, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	out = formatBoxPlot(stats, withinFences(skewed, stats, 1.5), 60)
+	if line := strings.SplitN(out, "\n", 2)[0]; strings.Count(line, "[") != 1 || strings.Count(line, "]") != 1 {
+		t.Errorf("median overlapping the box: expected both box edges:\n%s", out)
+	}
+}
+
+func TestInterpolatedHistogram(t *testing.T) {
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+	bins := computeHistogramBins(sorted, 16)
+
+	for name, ramp := range histogramRamps {
+		out := renderInterpolatedHistogram(bins, ramp)
+		runes := []rune(out)
+		if len(runes) != len(bins) {
+			t.Errorf("%s: expected %d runes, got %d (%q)", name, len(bins), len(runes), out)
+		}
+		valid := string(ramp)
+		for _, r := range runes {
+			if !strings.ContainsRune(valid, r) {
+				t.Errorf("%s: invalid character %q in %q", name, r, out)
+			}
+		}
+		// the tallest blended bar always reaches the top of the ramp
+		if !strings.ContainsRune(out, ramp[len(ramp)-1]) {
+			t.Errorf("%s: expected the top level in %q", name, out)
+		}
+	}
+}
+
+func TestComputeWeightedPercentile(t *testing.T) {
+	got, err := weightedMedian([]float64{1, 2, 3}, []float64{1, 1, 10})
+	if err != nil || got != 3 {
+		t.Errorf("weighted median: got %v (err %v), expected 3", got, err)
+	}
+
+	tests := []struct {
+		values, weights []float64
+		p, expected     float64
+	}{
+		// cumulative weight lands exactly on half the total: average the neighbours
+		{[]float64{4, 1, 3, 2}, []float64{1, 1, 1, 1}, 0.5, 2.5},
+		{[]float64{1, 2, 3}, []float64{1, 1, 10}, 0, 1},
+		{[]float64{1, 2, 3}, []float64{1, 1, 10}, 1, 3},
+		{[]float64{1, 2, 3}, []float64{5, 0, 5}, 0.5, 2}, // zero weight on 2 is skipped: (1+3)/2
+		{[]float64{10, 20, 30}, []float64{1, 2, 1}, 0.25, 15},
+	}
+	for _, tt := range tests {
+		got, err := computeWeightedPercentile(tt.values, tt.weights, tt.p)
+		if err != nil || !floatEquals(got, tt.expected) {
+			t.Errorf("computeWeightedPercentile(%v, %v, %v) = %v (err %v), expected %v", tt.values, tt.weights, tt.p, got, err, tt.expected)
+		}
+	}
+
+	for _, weights := range [][]float64{{1, 1}, {1, -1, 1}, {0, 0, 0}} {
+		if _, err := computeWeightedPercentile([]float64{1, 2, 3}, weights, 0.5); err == nil {
+			t.Errorf("expected error for weights %v", weights)
+		}
+	}
+}
+
+func TestTrendSlope(t *testing.T) {
+	stats, err := computeStats([]float64{1, 2, 3, 4}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if !floatEquals(stats.TrendSlope, 1.0) {
+		t.Errorf("TrendSlope: got %v, expected 1", stats.TrendSlope)
+	}
+
+	// slope follows input order, not sorted order
+	if slope, intercept := indexTrend([]float64{10, 7, 4, 1}); !floatEquals(slope, -3) || !floatEquals(intercept, 10) {
+		t.Errorf("indexTrend falling: got slope %v, intercept %v; expected -3, 10", slope, intercept)
+	}
+	if slope, intercept := indexTrend([]float64{5}); slope != 0 || intercept != 5 {
+		t.Errorf("indexTrend single value: got slope %v, intercept %v; expected 0, 5", slope, intercept)
+	}
+}
+
+func TestDetrend(t *testing.T) {
+	residuals, slope, intercept := detrend([]float64{1, 2, 3, 4})
+	if !floatEquals(slope, 1) || !floatEquals(intercept, 1) {
+		t.Errorf("got slope %v, intercept %v; expected 1, 1", slope, intercept)
+	}
+	for i, r := range residuals {
+		if math.Abs(r) > 1e-12 {
+			t.Errorf("residual %d: got %v, expected 0", i, r)
+		}
+	}
+
+	residuals, _, _ = detrend(testData)
+	var sum float64
+	for _, r := range residuals {
+		sum += r
+	}
+	if math.Abs(sum/float64(len(residuals))) > 1e-9 {
+		t.Errorf("residual mean: got %v, expected ~0", sum/float64(len(residuals)))
+	}
+	if got := formatFloat(sum); got != "0" {
+		t.Errorf("formatFloat of a near-zero residual sum: got %q, expected \"0\"", got)
+	}
+}
+
+func TestScaleToRange(t *testing.T) {
+	lo, hi, err := parseRange("0:100")
+	if err != nil {
+		t.Fatalf("parseRange returned error: %v", err)
+	}
+	if got := scaleToRange([]float64{0, 5, 10}, lo, hi); !floatSliceEquals(got, []float64{0, 50, 100}) {
+		t.Errorf("scale 0:100: got %v, expected [0 50 100]", got)
+	}
+	if got := scaleToRange([]float64{10, 0, 5}, -1, 1); !floatSliceEquals(got, []float64{1, -1, 0}) {
+		t.Errorf("scale -1:1 keeps input order: got %v, expected [1 -1 0]", got)
+	}
+	if got := scaleToRange([]float64{7, 7}, 3, 9); !floatSliceEquals(got, []float64{3, 3}) {
+		t.Errorf("constant data maps to A: got %v, expected [3 3]", got)
+	}
+
+	if lo, hi, err := parseRange("-1:1"); err != nil || lo != -1 || hi != 1 {
+		t.Errorf("parseRange(-1:1): got %v, %v, %v", lo, hi, err)
+	}
+	for _, bad := range []string{"1", "a:1", "1:", ""} {
+		if _, _, err := parseRange(bad); err == nil {
+			t.Errorf("parseRange(%q): expected error", bad)
+		}
+	}
+}
+
+func TestTotals(t *testing.T) {
+	stats, err := computeStats([]float64{-2, 3, -5}, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if stats.Sum != -4 || stats.SumAbs != 10 || stats.DistinctCount != 3 {
+		t.Errorf("got Sum %v, SumAbs %v, DistinctCount %d; expected -4, 10, 3", stats.Sum, stats.SumAbs, stats.DistinctCount)
+	}
+	if out := formatTotals(stats); !strings.Contains(out, "Sum |x|:   10\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestValueFrequenciesEps(t *testing.T) {
+	sorted := []float64{3, 4, 50, 50.0000001}
+
+	modes, dupes := modesAndDuplicates(valueFrequencies(sorted, 0))
+	if len(modes) != 0 || len(dupes) != 0 {
+		t.Errorf("exact: got modes %v, duplicates %v; expected none", modes, dupes)
+	}
+
+	freqs := valueFrequencies(sorted, 1e-6)
+	modes, dupes = modesAndDuplicates(freqs)
+	if len(freqs) != 3 || !floatSliceEquals(modes, []float64{50}) || len(dupes) != 1 || dupes[0].Count != 2 {
+		t.Errorf("eps 1e-6: got frequencies %v, modes %v, duplicates %v; expected mode 50 occurring twice", freqs, modes, dupes)
+	}
+
+	// eps 0 matches the exact frequencies computeStats uses
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	if !floatSliceEquals(stats.Mode, []float64{50}) || stats.Duplicates[0] != (ValueCount{Value: 50, Count: 4}) {
+		t.Errorf("testData: got mode %v, duplicates %v", stats.Mode, stats.Duplicates)
+	}
+}
+
+func TestHistogramDensities(t *testing.T) {
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+	bins := computeHistogramBins(sorted, 16)
+
+	area := 0.0
+	for i, d := range histogramDensities(bins) {
+		area += d * (bins[i].Upper - bins[i].Lower)
+	}
+	if !floatEquals(area, 1) {
+		t.Errorf("density times width sums to %v, expected 1", area)
+	}
+
+	d := 0.5
+	bins = []HistogramBin{{Lower: 0, Upper: 2, Count: 1, Density: &d}}
+	var out bytes.Buffer
+	if err := writeHistogramJSON(&out, bins, nil); err != nil {
+		t.Fatalf("writeHistogramJSON returned error: %v", err)
+	}
+	if got := out.String(); got != `[{"lower":0,"upper":2,"count":1,"density":0.5}]`+"\n" {
+		t.Errorf("unexpected JSON: %s", got)
+	}
+}
+
+func TestFilterRange(t *testing.T) {
+	lo, hi, err := parseOpenRange("0:100")
+	if err != nil {
+		t.Fatalf("parseOpenRange returned error: %v", err)
+	}
+	kept, excluded := filterRange(testData, lo, hi)
+	if len(kept) != 30 || excluded != 1 {
+		t.Errorf("-filter 0:100: got %d kept, %d excluded; expected 30 and 1", len(kept), excluded)
+	}
+	for _, v := range kept {
+		if v == 150 {
+			t.Errorf("150 should have been excluded")
+		}
+	}
+
+	if lo, hi, err := parseOpenRange(":20"); err != nil || !math.IsInf(lo, -1) || hi != 20 {
+		t.Errorf("parseOpenRange(:20): got %v, %v, %v", lo, hi, err)
+	}
+	if lo, hi, err := parseOpenRange("-5:"); err != nil || lo != -5 || !math.IsInf(hi, 1) {
+		t.Errorf("parseOpenRange(-5:): got %v, %v, %v", lo, hi, err)
+	}
+	for _, bad := range []string{"5", "x:1", "5:1"} {
+		if _, _, err := parseOpenRange(bad); err == nil {
+			t.Errorf("parseOpenRange(%q): expected error", bad)
+		}
+	}
+}
+
+func TestPercentChanges(t *testing.T) {
+	got, err := percentChanges([]float64{100, 110, 99})
+	if err != nil || !floatSliceEquals(got, []float64{10, -10}) {
+		t.Errorf("got %v (err %v), expected [10 -10]", got, err)
+	}
+	if _, err := percentChanges([]float64{100, 0, 99}); err == nil || !strings.Contains(err.Error(), "position 2") {
+		t.Errorf("expected an error for the zero previous value at position 2, got %v", err)
+	}
+	if _, err := percentChanges([]float64{5}); err == nil {
+		t.Errorf("expected an error for a single value")
+	}
+}
+
+func TestHealthScore(t *testing.T) {
+	clean := []float64{45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55}
+	cleanStats, err := computeStats(clean, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	cleanScore, err := healthScore(clean, cleanStats)
+	if err != nil {
+		t.Fatalf("healthScore returned error: %v", err)
+	}
+
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	score, err := healthScore(testData, stats)
+	if err != nil {
+		t.Fatalf("healthScore returned error: %v", err)
+	}
+
+	if cleanScore.Score < 90 {
+		t.Errorf("clean symmetric data: got score %v, expected >= 90", cleanScore.Score)
+	}
+	if score.Score >= cleanScore.Score {
+		t.Errorf("testData score %v should be below the clean score %v", score.Score, cleanScore.Score)
+	}
+	// 1 outlier in 31 values is 3.2258%, costing 32.2581 points
+	if !floatEquals(score.Outliers, 100-1000.0/31) || score.ChecksPassed != 3 {
+		t.Errorf("testData components: got outliers %v, %d checks passed", score.Outliers, score.ChecksPassed)
+	}
+	if !floatEquals(score.Score, (score.Stability+score.Outliers+score.Normality)/3) {
+		t.Errorf("score %v is not the average of its components", score.Score)
+	}
+}
+
+func TestFirstDifferenceRatio(t *testing.T) {
+	smooth := []float64{10, 11, 12, 13, 14, 15}
+	jagged := []float64{10, 18, 7, 19, 6, 20}
+	smoothV, err := firstDifferenceRatio(smooth, 12.5)
+	if err != nil {
+		t.Fatalf("smooth: %v", err)
+	}
+	jaggedV, err := firstDifferenceRatio(jagged, 13.3333)
+	if err != nil {
+		t.Fatalf("jagged: %v", err)
+	}
+	if !floatEquals(smoothV, 0.08) || jaggedV <= smoothV {
+		t.Errorf("got smooth %v, jagged %v; expected smooth 0.08 and jagged larger", smoothV, jaggedV)
+	}
+
+	if _, err := firstDifferenceRatio([]float64{5}, 5); err == nil {
+		t.Errorf("expected an error for a single value")
+	}
+	if _, err := firstDifferenceRatio([]float64{-1, 1}, 0); err == nil {
+		t.Errorf("expected an error for a zero mean")
+	}
+}
+
+func TestMergeSorted(t *testing.T) {
+	a := "1\n4\n4\n9\n\n12\n"
+	b := "2\n3\nskip\n10\n"
+	names := []string{"a", "b"}
+
+	var merged []float64
+	if err := mergeSorted(names, []io.Reader{strings.NewReader(a), strings.NewReader(b)}, func(v float64) {
+		merged = append(merged, v)
+	}); err != nil {
+		t.Fatalf("mergeSorted returned error: %v", err)
+	}
+	combined := []float64{1, 2, 3, 4, 4, 9, 10, 12}
+	if !floatSliceEquals(merged, combined) {
+		t.Errorf("merged %v, expected %v", merged, combined)
+	}
+
+	ps := []float64{0, 0.25, 0.5, 0.75, 0.9, 1}
+	got, err := mergedQuantiles(names, []io.Reader{strings.NewReader(a), strings.NewReader(b)}, len(combined), ps, calculatePercentile)
+	if err != nil {
+		t.Fatalf("mergedQuantiles returned error: %v", err)
+	}
+	for i, p := range ps {
+		if want := calculatePercentile(combined, p); !floatEquals(got[i], want) {
+			t.Errorf("p%v: got %v, expected %v", p*100, got[i], want)
+		}
+	}
+	if got[2] != 4 {
+		t.Errorf("median: got %v, expected 4", got[2])
+	}
+	got, err = mergedQuantiles(names, []io.Reader{strings.NewReader(a), strings.NewReader(b)}, len(combined), []float64{0.5, 0.3}, calculatePercentileMidpoint)
+	if err != nil || got[0] != 4 || got[1] != calculatePercentileMidpoint(combined, 0.3) {
+		t.Errorf("midpoint: got %v (err %v)", got, err)
+	}
+
+	err = mergeSorted([]string{"bad"}, []io.Reader{strings.NewReader("5\n3\n")}, func(float64) {})
+	if err == nil || !strings.Contains(err.Error(), "bad is not sorted") {
+		t.Errorf("expected an unsorted-input error, got %v", err)
+	}
+}
+
+func TestCohensD(t *testing.T) {
+	compute := func(data []float64) *Stats {
+		s, err := computeStats(data, nil, 1.5, 16, 0, 0, StatsOptions{})
+		if err != nil {
+			t.Fatalf("computeStats returned error: %v", err)
+		}
+		return s
+	}
+	// means 3 and 5, both variances 2.5: d = 2 / sqrt(2.5)
+	a, b := compute([]float64{1, 2, 3, 4, 5}), compute([]float64{3, 4, 5, 6, 7})
+	d, err := cohensD(a, b)
+	if err != nil || !floatEquals(d, 2/math.Sqrt(2.5)) || interpretCohensD(d) != "large" {
+		t.Errorf("got d %v (%s), err %v; expected %v (large)", d, interpretCohensD(d), err, 2/math.Sqrt(2.5))
+	}
+	if d, _ := cohensD(b, a); d >= 0 {
+		t.Errorf("d should be negative when the second group's mean is lower, got %v", d)
+	}
+
+	for d, want := range map[float64]string{0.1: "negligible", -0.3: "small", 0.5: "medium", -0.8: "large"} {
+		if got := interpretCohensD(d); got != want {
+			t.Errorf("interpretCohensD(%v) = %q, expected %q", d, got, want)
+		}
+	}
+
+	if _, err := cohensD(compute([]float64{5}), b); err == nil {
+		t.Errorf("expected an error for a single-value group")
+	}
+
+	// Without -baseline there is nothing to compare against
+	cmd := exec.Command("go", "run", "stats.go", "-cohens-d", "test_data.txt")
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected non-zero exit for -cohens-d without -baseline, got success: %s", output)
+	}
+	if !strings.Contains(string(output), "-cohens-d requires -baseline") {
+		t.Errorf("expected a usage error, got: %s", output)
+	}
+}
+
+func TestDeciles(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	sorted := make([]float64, len(testData))
+	copy(sorted, testData)
+	sort.Float64s(sorted)
+
+	got := deciles(sorted, calculatePercentile)
+	if len(got) != 9 {
+		t.Fatalf("expected 9 deciles, got %d: %v", len(got), got)
+	}
+	if !sort.Float64sAreSorted(got) {
+		t.Errorf("deciles not ascending: %v", got)
+	}
+	if got[4] != stats.Median || got[0] != stats.P10 {
+		t.Errorf("P50 %v and P10 %v should equal the median %v and P10 %v", got[4], got[0], stats.Median, stats.P10)
+	}
+}
+
+func TestRobustStandardize(t *testing.T) {
+	got, median, scale, err := robustStandardize(testData)
+	if err != nil {
+		t.Fatalf("robustStandardize returned error: %v", err)
+	}
+	if median != 50 || !floatEquals(scale, 1.4826*25) {
+		t.Errorf("got median %v, scale %v; expected 50, %v", median, scale, 1.4826*25)
+	}
+	sort.Float64s(got)
+	if m := calculatePercentile(got, 0.5); math.Abs(m) > 1e-12 {
+		t.Errorf("robust-standardized median: got %v, expected 0", m)
+	}
+
+	if _, _, _, err := robustStandardize([]float64{5, 5, 5, 9}); err == nil {
+		t.Errorf("expected an error when MAD is 0")
+	}
+}
+
+func TestDigitBias(t *testing.T) {
+	var heaped []float64
+	for i := 1; i <= 40; i++ {
+		heaped = append(heaped, float64(i*5)) // every value ends in 0 or 5
+	}
+	heaped = append(heaped, 12, 23, 37, 41, 68, 99, 2.5)
+	b, err := digitBias(heaped)
+	if err != nil {
+		t.Fatalf("digitBias returned error: %v", err)
+	}
+	if !b.Heaping || b.Total != 46 || b.Skipped != 1 || b.Counts[0] != 20 || b.Counts[5] != 20 {
+		t.Errorf("heaped data: got %+v, expected heaping with 20 zeros and 20 fives out of 46", b)
+	}
+	if !strings.Contains(formatDigitBias(b), "Heaping:     yes") {
+		t.Errorf("unexpected output:\n%s", formatDigitBias(b))
+	}
+
+	var uniform []float64
+	for i := 0; i < 100; i++ {
+		uniform = append(uniform, float64(i))
+	}
+	if b, _ := digitBias(uniform); b.Heaping || b.ChiSquare != 0 || !floatEquals(b.RoundShare, 0.2) {
+		t.Errorf("uniform digits: got %+v, expected no heaping", b)
+	}
+
+	if _, err := digitBias([]float64{1.5, 2.25}); err == nil {
+		t.Errorf("expected an error for data without integers")
+	}
+}
+
+func TestWriteSummaryJSON(t *testing.T) {
+	stats, err := computeStats(testData, []float64{90}, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := writeSummaryJSON(&buf, stats); err != nil {
+		t.Fatalf("writeSummaryJSON returned error: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if got["SkewnessInterpretation"] != "Moderately Right Skewed" {
+		t.Errorf("SkewnessInterpretation: got %v, expected \"Moderately Right Skewed\"", got["SkewnessInterpretation"])
+	}
+	if got["KurtosisInterpretation"] != "Mesokurtic - normal-like" || got["CVInterpretation"] != "High Variability" {
+		t.Errorf("got kurtosis %v, CV %v", got["KurtosisInterpretation"], got["CVInterpretation"])
+	}
+	if skew, ok := got["Skewness"].(float64); !ok || !floatEquals(skew, 0.7271) {
+		t.Errorf("Skewness: got %v, expected 0.7271 alongside its label", got["Skewness"])
+	}
+}
+
+func TestComparePercentiles(t *testing.T) {
+	a := []float64{10, 20, 30, 40, 50}
+	b := []float64{5, 15, 25, 35, 45, 55, 65}
+	rows := comparePercentiles(a, b, []float64{50, 90}, calculatePercentile)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	// medians are 30 and 35
+	if rows[0].P != 50 || rows[0].A != 30 || rows[0].B != 35 || rows[0].Delta != 5 {
+		t.Errorf("P50: got %+v, expected A=30, B=35, Delta=5", rows[0])
+	}
+	if want := calculatePercentile(b, 0.9) - calculatePercentile(a, 0.9); !floatEquals(rows[1].Delta, want) {
+		t.Errorf("P90 delta: got %v, expected %v", rows[1].Delta, want)
+	}
+	if out := formatPercentileComparison(rows); !strings.Contains(out, "P50         30  35  +5     +16.6667%") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestWeightedHistogramBins(t *testing.T) {
+	// most values sit in the low bins, but the few values near 10 carry large weights
+	values := []float64{1, 1.5, 2, 2, 2.5, 3, 3, 3.5, 4, 9.8, 10}
+	weights := []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 50, 50}
+
+	counted := computeHistogramBins([]float64{1, 1.5, 2, 2, 2.5, 3, 3, 3.5, 4, 9.8, 10}, 5)
+	weighted := computeWeightedHistogramBins(values, weights, 5)
+	if len(weighted) != 5 {
+		t.Fatalf("expected 5 bins, got %d", len(weighted))
+	}
+	for i, b := range weighted {
+		if b.Weight == nil {
+			t.Fatalf("bin %d: weighted bins must all carry a weight, got nil", i)
+		}
+	}
+	if last := weighted[4]; last.Count != 2 || *last.Weight != 100 {
+		t.Errorf("last bin: got count %d, weight %v; expected 2 and 100", last.Count, *last.Weight)
+	}
+	for i, b := range counted {
+		if b.Weight != nil {
+			t.Errorf("unweighted bin %d: got weight %v, expected nil", i, *b.Weight)
+		}
+	}
+
+	top := string(defaultRamp[len(defaultRamp)-1])
+	if got := renderHistogram(weighted, defaultRamp); !strings.HasSuffix(got, top) || strings.Count(got, top) != 1 {
+		t.Errorf("weighted sparkline %q: expected only the last bin at the top level", got)
+	}
+	if got := renderHistogram(counted, defaultRamp); strings.HasSuffix(got, top) {
+		t.Errorf("unweighted sparkline %q: the last bin should not dominate", got)
+	}
+
+	var buf bytes.Buffer
+	if err := writeHistogramJSON(&buf, weighted[4:], nil); err != nil || !strings.Contains(buf.String(), `"count":2,"weight":100`) {
+		t.Errorf("JSON: got %s (err %v), expected the summed weight", buf.String(), err)
+	}
+}
+
+func TestTopVarianceContributors(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+	top := topVarianceContributors(testData, stats.Mean, 3)
+	if len(top) != 3 {
+		t.Fatalf("got %d contributors, expected 3", len(top))
+	}
+	// 150 alone accounts for over a quarter of the sum of squares
+	if top[0].Value != 150 || top[0].Share < 25 {
+		t.Errorf("top contributor: got %+v, expected 150 with a share above 25%%", top[0])
+	}
+	if !(top[0].SquaredDev >= top[1].SquaredDev && top[1].SquaredDev >= top[2].SquaredDev) {
+		t.Errorf("contributors not sorted by squared deviation: %+v", top)
+	}
+
+	all := topVarianceContributors(testData, stats.Mean, 100)
+	total := 0.0
+	for _, c := range all {
+		total += c.Share
+	}
+	if len(all) != len(testData) || !floatEquals(total, 100) {
+		t.Errorf("all contributors: got %d with shares summing to %v, expected %d summing to 100", len(all), total, len(testData))
+	}
+
+	if got := topVarianceContributors([]float64{4, 4, 4}, 4, 2); got != nil {
+		t.Errorf("constant data: got %+v, expected nil", got)
+	}
+}
+
+func TestConsensusOutliers(t *testing.T) {
+	stats, err := computeStats(testData, nil, 1.5, 16, 0, 0, StatsOptions{})
+	if err != nil {
+		t.Fatalf("computeStats returned error: %v", err)
+	}
+
+	// 150 is the only IQR outlier, and its modified z-score is 0.6745 * (150 - 50) / 25 = 2.70
+	got := consensusOutliers(stats.Outliers, stats.Median, stats.MAD, defaultConsensusModZ)
+	if len(got) != 1 || got[0] != 150 {
+		t.Errorf("default threshold: got %v, expected [150]", got)
+	}
+
+	// 100 and 95 have modified z-scores above 1 but are inside the IQR fences, so the
+	// modified z-score's vote alone is not enough
+	if got := consensusOutliers(stats.Outliers, stats.Median, stats.MAD, 1); len(got) != 1 || got[0] != 150 {
+		t.Errorf("threshold 1: got %v, expected only [150]", got)
+	}
+
+	if got := consensusOutliers([]float64{150}, 5, 0, defaultConsensusModZ); got != nil {
+		t.Errorf("MAD 0: got %v, expected nil", got)
+	}
+}
+
+func TestEqualFrequencyBins(t *testing.T) {
+	sorted := append([]float64(nil), testData...)
+	sort.Float64s(sorted)
+	for _, numBins := range []int{4, 5, 8} {
+		bins := computeEqualFrequencyBins(sorted, numBins)
+		if len(bins) != numBins {
+			t.Fatalf("%d bins: got %d", numBins, len(bins))
+		}
+		lo, hi, total := bins[0].Count, bins[0].Count, 0
+		for i, b := range bins {
+			lo, hi, total = min(lo, b.Count), max(hi, b.Count), total+b.Count
+			if b.Upper < b.Lower || (i > 0 && b.Lower != bins[i-1].Upper) {
+				t.Errorf("%d bins: bin %d range [%v, %v] is not contiguous", numBins, i, b.Lower, b.Upper)
+			}
+		}
+		if hi-lo > 1 || total != len(sorted) {
+			t.Errorf("%d bins: counts range %d..%d over %d values; expected a spread of at most 1 over %d", numBins, lo, hi, total, len(sorted))
+		}
+		if bins[0].Lower != sorted[0] || bins[numBins-1].Upper != sorted[len(sorted)-1] {
+			t.Errorf("%d bins: got range [%v, %v], expected [%v, %v]", numBins, bins[0].Lower, bins[numBins-1].Upper, sorted[0], sorted[len(sorted)-1])
+		}
+	}
+
+	if bins := computeEqualFrequencyBins([]float64{5, 5, 5}, 4); bins != nil {
+		t.Errorf("constant data: got %v, expected nil", bins)
+	}
+	// one bin per value, but the last would be the zero-width [3, 3] and joins [2, 3]
+	if bins := computeEqualFrequencyBins([]float64{1, 2, 3}, 10); len(bins) != 2 || bins[1].Count != 2 {
+		t.Errorf("more bins than values: got %+v, expected 2 bins with 1 and 2 values", bins)
+	}
+
+	// heavily tied data: zero-width bins of tied values are merged, so no bin has zero density
+	// and the tallest bar sits where the data is
+	for _, tt := range []struct {
+		data  []float64
+		dense float64 // Lower of the bin holding the ties
+	}{
+		{[]float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 2}, 1},
+		{[]float64{1, 2, 2, 2, 2, 2, 2, 2, 2, 2}, 1},
+		{[]float64{1, 2, 3, 4, 5, 5, 5, 5, 5, 5, 5, 5, 6, 7, 8, 9}, 5},
+	} {
+		bins := computeEqualFrequencyBins(tt.data, 5)
+		densities := histogramDensities(bins)
+		total, tallest := 0, 0
+		for i, b := range bins {
+			total += b.Count
+			if b.Upper <= b.Lower || densities[i] <= 0 {
+				t.Errorf("%v: bin %+v has zero width or density", tt.data, b)
+			}
+			if densities[i] > densities[tallest] {
+				tallest = i
+			}
+		}
+		if total != len(tt.data) || bins[tallest].Lower != tt.dense {
+			t.Errorf("%v: got %+v, expected %d values with the densest bin starting at %v", tt.data, bins, len(tt.data), tt.dense)
+		}
+	}
+}
+
+func TestWeightColumnHistogram(t *testing.T) {
+	// the two values near 10 carry most of the weight, so their bin holds most of the density
+	input := "1,1\n1.5,1\n2,1\n2,1\n2.5,1\n3,1\n3,1\n3.5,1\n4,1\n9.8,50\n10,50\n"
+	cmd := exec.Command("go", "run", "stats.go", "-column", "1", "-weight-column", "2", "-b", "5", "-hist-json", "-hist-density", "-")
+	cmd.Stdin = strings.NewReader(input)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, output)
+	}
+	var bins []HistogramBin
+	if err := json.Unmarshal(output, &bins); err != nil {
+		t.Fatalf("invalid JSON: %v: %s", err, output)
+	}
+	if len(bins) != 5 || bins[4].Weight == nil || *bins[4].Weight != 100 || bins[4].Density == nil || !(*bins[4].Density > *bins[0].Density) {
+		t.Errorf("got %s, expected the last bin to carry weight 100 and the highest density", output)
+	}
+
+	densities := histogramDensities(bins)
+	area := 0.0
+	for i, b := range bins {
+		area += densities[i] * (b.Upper - b.Lower)
+	}
+	if !floatEquals(area, 1) {
+		t.Errorf("weighted densities integrate to %v, expected 1", area)
+	}
+}
+
+func TestTrendFitQuality(t *testing.T) {
+	// y = 2 + 3x with alternating noise of ±1
+	noisy := []float64{3, 4, 9, 10, 15, 16, 21, 22}
+	fit, err := trendFitQuality(noisy)
+	if err != nil {
+		t.Fatalf("trendFitQuality returned error: %v", err)
+	}
+	if !(fit.RMSE > 0) || math.IsInf(fit.RMSE, 0) || !(fit.ResidualSD > fit.RMSE) {
+		t.Errorf("got RMSE %v, residual SD %v; expected positive, finite, and SD > RMSE", fit.RMSE, fit.ResidualSD)
+	}
+	if math.Abs(fit.MaxResidual) < fit.RMSE {
+		t.Errorf("max residual %v smaller than RMSE %v", fit.MaxResidual, fit.RMSE)
+	}
+
+	perfect, err := trendFitQuality([]float64{1, 2, 3, 4})
+	if err != nil || perfect.RMSE > 1e-12 || !floatEquals(perfect.Slope, 1) {
+		t.Errorf("perfect line: got %+v (err %v), expected RMSE 0 and slope 1", perfect, err)
+	}
+
+	if _, err := trendFitQuality([]float64{1, 2}); err == nil {
+		t.Errorf("expected an error for 2 values")
+	}
+}